@@ -0,0 +1,769 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/types"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jacobbrewer1/patcher"
+)
+
+const (
+	// patcherPath is the import path of the patcher package
+	patcherPath = "github.com/jacobbrewer1/patcher"
+
+	// buildTag excludes the generated files while the package is loaded
+	buildTag = "patchergen"
+)
+
+var (
+	// errUnsupportedField is returned when a struct has a field the generated builders cannot handle
+	errUnsupportedField = errors.New("unsupported field")
+
+	// errInvalidStruct is returned when a requested type is not a struct the builders can be generated for
+	errInvalidStruct = errors.New("invalid struct")
+
+	// unsupportedOpts are the patcher tag options handled by reflection only
+	unsupportedOpts = []string{
+		patcher.TagOptVersion,
+		patcher.TagOptIncrement,
+		patcher.TagOptJSON,
+		patcher.TagOptAutoCreate,
+		patcher.TagOptAutoUpdate,
+	}
+)
+
+// generator generates the builders of the structs of a package
+type generator struct {
+	// pkg is the package the builders are generated for
+	pkg *types.Package
+
+	// imports maps the import path of the packages referenced by the generated code to their name
+	imports map[string]string
+}
+
+// generate generates the source of the builders of the named struct types, or of every struct in the package with at
+// least one db or patcher tag when no types are named.
+func generate(pkg *types.Package, typeNames []string) ([]byte, error) {
+	structs, err := lookupStructs(pkg, typeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		pkg:     pkg,
+		imports: map[string]string{patcherPath: "patcher"},
+	}
+
+	body := new(bytes.Buffer)
+	for _, named := range structs {
+		if err := g.genType(body, named); err != nil {
+			return nil, err
+		}
+	}
+
+	src := new(bytes.Buffer)
+	src.WriteString("// Code generated by patchergen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(src, "//go:build !%s\n\n", buildTag)
+	fmt.Fprintf(src, "package %s\n\n", pkg.Name())
+
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Standard library packages are imported first, separated from the other packages
+	sort.SliceStable(paths, func(i, j int) bool {
+		return isStdlib(paths[i]) && !isStdlib(paths[j])
+	})
+
+	src.WriteString("import (\n")
+	for i, path := range paths {
+		if i > 0 && isStdlib(paths[i-1]) && !isStdlib(path) {
+			src.WriteString("\n")
+		}
+
+		if name := g.imports[path]; name != lastPathElem(path) {
+			fmt.Fprintf(src, "%s %q\n", name, path)
+			continue
+		}
+		fmt.Fprintf(src, "%q\n", path)
+	}
+	src.WriteString(")\n\n")
+
+	src.WriteString("var (\n")
+	for _, named := range structs {
+		name := named.Obj().Name()
+		fmt.Fprintf(src, "_ patcher.Patchable = (*%s)(nil)\n", name)
+		fmt.Fprintf(src, "_ patcher.DiffLoader[%s] = (*%s)(nil)\n", name, name)
+		fmt.Fprintf(src, "_ patcher.Insertable = (*%s)(nil)\n", name)
+	}
+	src.WriteString(")\n\n")
+
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated source: %w", err)
+	}
+
+	return formatted, nil
+}
+
+// lookupStructs returns the named struct types, or every struct in the package with at least one db or patcher tag
+// when no types are named.
+func lookupStructs(pkg *types.Package, typeNames []string) ([]*types.Named, error) {
+	if len(typeNames) == 0 {
+		structs := make([]*types.Named, 0)
+		for _, name := range pkg.Scope().Names() {
+			named, st, ok := structType(pkg.Scope().Lookup(name))
+			if ok && named.TypeParams().Len() == 0 && hasTags(st) {
+				structs = append(structs, named)
+			}
+		}
+
+		if len(structs) == 0 {
+			return nil, fmt.Errorf("%w: no structs with db or patcher tags in package %s", errInvalidStruct, pkg.Path())
+		}
+
+		return structs, nil
+	}
+
+	structs := make([]*types.Named, 0, len(typeNames))
+	for _, name := range typeNames {
+		named, _, ok := structType(pkg.Scope().Lookup(strings.TrimSpace(name)))
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%w: %s is not a struct type in package %s", errInvalidStruct, name, pkg.Path())
+		case named.TypeParams().Len() > 0:
+			return nil, fmt.Errorf("%w: %s is a generic type", errInvalidStruct, name)
+		}
+
+		structs = append(structs, named)
+	}
+
+	return structs, nil
+}
+
+// structType returns the named struct type of the object
+func structType(obj types.Object) (*types.Named, *types.Struct, bool) {
+	typeName, ok := obj.(*types.TypeName)
+	if !ok || typeName.IsAlias() {
+		return nil, nil, false
+	}
+
+	named, ok := typeName.Type().(*types.Named)
+	if !ok {
+		return nil, nil, false
+	}
+
+	st, ok := named.Underlying().(*types.Struct)
+	return named, st, ok
+}
+
+// hasTags checks if any field of the struct has a db or patcher tag
+func hasTags(st *types.Struct) bool {
+	for i := range st.NumFields() {
+		tag := reflect.StructTag(st.Tag(i))
+		if _, ok := tag.Lookup(patcher.DefaultDbTagName); ok {
+			return true
+		}
+		if _, ok := tag.Lookup(patcher.TagOptsName); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// genType generates the builders of the struct type
+func (g *generator) genType(w *bytes.Buffer, named *types.Named) error {
+	name := named.Obj().Name()
+	st, _ := named.Underlying().(*types.Struct)
+
+	if err := g.validate(st, name, make(map[*types.Struct]bool)); err != nil {
+		return err
+	}
+
+	patch := new(patchWriter)
+	if err := g.patchFields(patch, st, "v.", ""); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	fmt.Fprintf(w, "// PatchFields returns the columns and arguments of the fields of %s to set in a patch.\n", name)
+	fmt.Fprintf(w, "func (v %s) PatchFields() ([]string, []any) {\n", name)
+	if patch.numFields == 0 {
+		w.WriteString("return nil, nil\n}\n\n")
+	} else {
+		fmt.Fprintf(w, "columns := make([]string, 0, %d)\n", patch.numFields)
+		fmt.Fprintf(w, "args := make([]any, 0, %d)\n", patch.numFields)
+		w.Write(patch.fields.Bytes())
+		w.WriteString("return columns, args\n}\n\n")
+	}
+
+	fmt.Fprintf(w, "// PatchKeys returns the columns and values of the primary key fields of %s.\n", name)
+	fmt.Fprintf(w, "func (v %s) PatchKeys() ([]string, []any) {\n", name)
+	if patch.numKeys == 0 {
+		w.WriteString("return nil, nil\n}\n\n")
+	} else {
+		fmt.Fprintf(w, "columns := make([]string, 0, %d)\n", patch.numKeys)
+		fmt.Fprintf(w, "values := make([]any, 0, %d)\n", patch.numKeys)
+		w.Write(patch.keys.Bytes())
+		w.WriteString("return columns, values\n}\n\n")
+	}
+
+	diff := new(bytes.Buffer)
+	if err := g.loadDiff(diff, st, "o.", "n."); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	fmt.Fprintf(w, "// LoadDiffFrom loads the non-zero fields of n into the %s.\n", name)
+	fmt.Fprintf(w, "func (o *%s) LoadDiffFrom(n *%s) {\n", name, name)
+	w.Write(diff.Bytes())
+	w.WriteString("}\n\n")
+
+	fmt.Fprintf(w, "// InsertFields returns the columns and arguments of the fields of %s to insert.\n", name)
+	fmt.Fprintf(w, "func (v %s) InsertFields() ([]string, []any) {\n", name)
+	g.insertFields(w, st)
+	w.WriteString("}\n\n")
+
+	return nil
+}
+
+// validate checks that every exported field of the struct, and of its nested structs, is supported by the generated
+// builders
+func (g *generator) validate(st *types.Struct, owner string, seen map[*types.Struct]bool) error {
+	if seen[st] {
+		return nil
+	}
+	seen[st] = true
+
+	for i := range st.NumFields() {
+		f := newStructField(st, i)
+		if !f.v.Exported() {
+			continue
+		}
+
+		for _, opt := range unsupportedOpts {
+			if f.has(opt) {
+				return fmt.Errorf("%w: %s.%s is tagged with the %s option", errUnsupportedField, owner, f.name, opt)
+			}
+		}
+
+		base := derefType(f.v.Type())
+		if isPatcherType(base, "Optional") || isPatcherType(base, "Expression") {
+			return fmt.Errorf("%w: %s.%s is of type %s", errUnsupportedField, owner, f.name, g.typeString(base))
+		}
+
+		nested, ok := base.Underlying().(*types.Struct)
+		if f.v.Anonymous() && !ok {
+			return fmt.Errorf("%w: embedded field %s.%s is not a struct", errUnsupportedField, owner, f.name)
+		}
+
+		if ok && !g.isScalarStruct(base) {
+			if err := g.validate(nested, owner+"."+f.name, seen); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// patchWriter holds the generated statements of the PatchFields and PatchKeys methods
+type patchWriter struct {
+	fields    bytes.Buffer
+	numFields int
+	keys      bytes.Buffer
+	numKeys   int
+}
+
+// merge adds the statements of the flattened struct pointer, guarded by a nil check of the pointer
+func (w *patchWriter) merge(child *patchWriter, sel string) {
+	if child.numFields > 0 {
+		fmt.Fprintf(&w.fields, "if %s != nil {\n%s}\n", sel, child.fields.Bytes())
+		w.numFields += child.numFields
+	}
+
+	if child.numKeys > 0 {
+		fmt.Fprintf(&w.keys, "if %s != nil {\n%s}\n", sel, child.keys.Bytes())
+		w.numKeys += child.numKeys
+	}
+}
+
+// patchFields generates the statements adding the fields of the struct to the patch, in the same way as
+// patcher.NewSQLPatch with the default options. Flattened structs are expanded into their columns.
+func (g *generator) patchFields(w *patchWriter, st *types.Struct, recv, prefix string) error {
+	for i := range st.NumFields() {
+		f := newStructField(st, i)
+		if !f.v.Exported() {
+			continue
+		}
+
+		sel := recv + f.name
+		typ := f.v.Type()
+		_, isPtr := isPointer(typ)
+
+		if g.isFlattened(f) {
+			if f.has(patcher.TagOptSkip) {
+				continue
+			}
+
+			nested, _ := derefType(typ).Underlying().(*types.Struct)
+			if !isPtr {
+				if err := g.patchFields(w, nested, sel+".", prefix+f.prefix); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// The fields of a nil struct pointer are omitted
+			child := new(patchWriter)
+			if err := g.patchFields(child, nested, sel+".", prefix+f.prefix); err != nil {
+				return err
+			}
+			w.merge(child, sel)
+			continue
+		}
+
+		column := strconv.Quote(prefix + f.column)
+		arg := g.argExpr(sel, typ)
+
+		if f.isPrimaryKey() {
+			nonZero, err := g.nonZeroKey(sel, typ)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+
+			fmt.Fprintf(&w.keys, "columns = append(columns, %s)\n", column)
+			fmt.Fprintf(&w.keys, "if %s {\nvalues = append(values, %s)\n} else {\nvalues = append(values, nil)\n}\n", nonZero, arg)
+			w.numKeys++
+			continue
+		}
+
+		if !isValidType(typ) || f.has(patcher.TagOptSkip) {
+			continue
+		}
+
+		w.numFields++
+		if f.has(patcher.TagOptOmitempty) {
+			// Zero and nil values are included
+			fmt.Fprintf(&w.fields, "columns = append(columns, %s)\n", column)
+			if isPtr {
+				fmt.Fprintf(&w.fields, "if %s != nil {\nargs = append(args, %s)\n} else {\nargs = append(args, nil)\n}\n", sel, arg)
+			} else {
+				fmt.Fprintf(&w.fields, "args = append(args, %s)\n", arg)
+			}
+			continue
+		}
+
+		nonZero, err := g.nonZero(sel, typ)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		fmt.Fprintf(&w.fields, "if %s {\n", nonZero)
+		if g.isValuer(typ) {
+			// A driver.Valuer resolving to NULL is omitted
+			fmt.Fprintf(&w.fields, "if value, err := %s.Value(); err != nil || value != nil {\n", sel)
+		}
+		fmt.Fprintf(&w.fields, "columns = append(columns, %s)\nargs = append(args, %s)\n", column, arg)
+		if g.isValuer(typ) {
+			w.fields.WriteString("}\n")
+		}
+		w.fields.WriteString("}\n")
+	}
+
+	return nil
+}
+
+// loadDiff generates the statements loading the fields of the new struct into the old struct, in the same way as
+// patcher.LoadDiff with the default options. Embedded and nested structs are loaded field by field.
+func (g *generator) loadDiff(w *bytes.Buffer, st *types.Struct, oldRecv, newRecv string) error {
+	for i := range st.NumFields() {
+		f := newStructField(st, i)
+		if !f.v.Exported() {
+			continue
+		}
+
+		oldSel, newSel := oldRecv+f.name, newRecv+f.name
+		typ := f.v.Type()
+		_, isPtr := isPointer(typ)
+		nested, isStruct := derefType(typ).Underlying().(*types.Struct)
+
+		if f.v.Anonymous() && isPtr {
+			fmt.Fprintf(w, "switch {\ncase %s != nil && %s != nil:\n", oldSel, newSel)
+			if err := g.loadDiff(w, nested, oldSel+".", newSel+"."); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "case %s != nil:\n%s = %s\n", newSel, oldSel, newSel)
+			if f.hasColumnOpt(patcher.TagOptOmitempty) {
+				fmt.Fprintf(w, "default:\n%s = nil\n", oldSel)
+			}
+			w.WriteString("}\n")
+			continue
+		}
+
+		if isStruct && !isPtr && (f.v.Anonymous() || !g.isScalarStruct(typ)) {
+			if err := g.loadDiff(w, nested, oldSel+".", newSel+"."); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if f.has(patcher.TagOptSkip) {
+			continue
+		}
+
+		if f.has(patcher.TagOptOmitempty) {
+			fmt.Fprintf(w, "%s = %s\n", oldSel, newSel)
+			continue
+		}
+
+		nonZero, err := g.nonZero(newSel, typ)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		fmt.Fprintf(w, "if %s {\n", nonZero)
+		if g.isValuer(typ) {
+			// A driver.Valuer resolving to NULL is not loaded
+			fmt.Fprintf(w, "if value, err := %s.Value(); err != nil || value != nil {\n%s = %s\n}\n", newSel, oldSel, newSel)
+		} else {
+			fmt.Fprintf(w, "%s = %s\n", oldSel, newSel)
+		}
+		w.WriteString("}\n")
+	}
+
+	return nil
+}
+
+// insertFields generates the body of the InsertFields method, in the same way as inserter.NewBatch with the default
+// options. Nested structs are inserted as a single value.
+func (g *generator) insertFields(w *bytes.Buffer, st *types.Struct) {
+	columns := make([]string, 0, st.NumFields())
+	args := new(bytes.Buffer)
+
+	for i := range st.NumFields() {
+		f := newStructField(st, i)
+		typ := f.v.Type()
+		if !f.v.Exported() || !isValidType(typ) || f.has(patcher.TagOptSkip) || f.isPrimaryKey() || f.tag == patcher.TagOptSkip {
+			continue
+		}
+
+		column := f.tag
+		if column == "" {
+			column = f.name
+		}
+		columns = append(columns, strconv.Quote(column))
+
+		sel := "v." + f.name
+		if _, isPtr := isPointer(typ); isPtr {
+			fmt.Fprintf(args, "if %s != nil {\nargs = append(args, *%s)\n} else {\nargs = append(args, nil)\n}\n", sel, sel)
+			continue
+		}
+
+		fmt.Fprintf(args, "args = append(args, %s)\n", sel)
+	}
+
+	if len(columns) == 0 {
+		w.WriteString("return nil, nil\n")
+		return
+	}
+
+	fmt.Fprintf(w, "args := make([]any, 0, %d)\n", len(columns))
+	w.Write(args.Bytes())
+	fmt.Fprintf(w, "return []string{%s}, args\n", strings.Join(columns, ", "))
+}
+
+// argExpr returns the expression of the argument of the field. Pointers are dereferenced, except for driver.Valuer
+// implementations which are resolved by the patcher.
+func (g *generator) argExpr(sel string, typ types.Type) string {
+	_, isPtr := isPointer(typ)
+	switch {
+	case isPtr && implementsValuer(typ):
+		return sel
+	case isPtr:
+		return "*" + sel
+	case implementsValuer(typ):
+		return sel
+	case g.isValuer(typ):
+		return "&" + sel
+	default:
+		return sel
+	}
+}
+
+// nonZero returns the condition checking that the field does not hold its zero value
+func (g *generator) nonZero(sel string, typ types.Type) (string, error) {
+	zero, err := g.zeroValue(typ)
+	if err != nil {
+		return "", err
+	}
+
+	return sel + " != " + zero, nil
+}
+
+// nonZeroKey returns the condition checking that the primary key does not hold its zero value. Pointers are checked
+// for both nil and the zero value of the element.
+func (g *generator) nonZeroKey(sel string, typ types.Type) (string, error) {
+	ptr, isPtr := isPointer(typ)
+	if !isPtr {
+		return g.nonZero(sel, typ)
+	}
+
+	elemNonZero, err := g.nonZero("*"+sel, ptr.Elem())
+	if err != nil {
+		return "", err
+	}
+
+	return sel + " != nil && " + elemNonZero, nil
+}
+
+// zeroValue returns the expression of the zero value of the type
+func (g *generator) zeroValue(typ types.Type) (string, error) {
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return `""`, nil
+		case u.Info()&types.IsBoolean != 0:
+			return "false", nil
+		case u.Info()&types.IsNumeric != 0:
+			return "0", nil
+		default:
+			return "nil", nil
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", nil
+	case *types.Struct, *types.Array:
+		if !types.Comparable(typ) {
+			return "", fmt.Errorf("%w: %s is not comparable", errUnsupportedField, g.typeString(typ))
+		}
+		return "(" + g.typeString(typ) + "{})", nil
+	default:
+		return "", fmt.Errorf("%w: %s has no zero value", errUnsupportedField, g.typeString(typ))
+	}
+}
+
+// isFlattened determines whether the field is a struct flattened into its columns, following the same rules as the
+// patcher: exported embedded structs are always flattened, named structs when tagged with inline or prefix.
+func (g *generator) isFlattened(f structField) bool {
+	base := derefType(f.v.Type())
+	if _, ok := base.Underlying().(*types.Struct); !ok || !f.v.Exported() || g.isScalarStruct(base) {
+		return false
+	}
+
+	return f.v.Anonymous() || f.hasPrefix || f.has(patcher.TagOptInline)
+}
+
+// isScalarStruct checks if the struct type is stored as a single database value, such as time.Time and
+// driver.Valuer implementations
+func (g *generator) isScalarStruct(typ types.Type) bool {
+	if named, ok := typ.(*types.Named); ok {
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return true
+		}
+	}
+
+	return isPatcherType(typ, "Expression") || g.isValuer(typ)
+}
+
+// isValuer checks if the type, or a pointer to it, implements driver.Valuer
+func (g *generator) isValuer(typ types.Type) bool {
+	if implementsValuer(typ) {
+		return true
+	}
+
+	_, isPtr := isPointer(typ)
+	return !isPtr && implementsValuer(types.NewPointer(typ))
+}
+
+// typeString returns the type as written in the generated code, recording the packages to import
+func (g *generator) typeString(typ types.Type) string {
+	return types.TypeString(typ, g.qualifier)
+}
+
+// qualifier returns the name used for the package in the generated code
+func (g *generator) qualifier(pkg *types.Package) string {
+	if pkg == g.pkg {
+		return ""
+	}
+
+	if name, ok := g.imports[pkg.Path()]; ok {
+		return name
+	}
+
+	name := pkg.Name()
+	for i := 2; g.nameInUse(name); i++ {
+		name = pkg.Name() + strconv.Itoa(i)
+	}
+
+	g.imports[pkg.Path()] = name
+	return name
+}
+
+// nameInUse checks if the package name is used by an import or a declaration of the package
+func (g *generator) nameInUse(name string) bool {
+	for _, used := range g.imports {
+		if used == name {
+			return true
+		}
+	}
+
+	return g.pkg.Scope().Lookup(name) != nil
+}
+
+// structField is a field of a struct with its parsed db and patcher tags
+type structField struct {
+	v *types.Var
+
+	// name is the name of the field
+	name string
+
+	// tag is the column name set in the db tag
+	tag string
+
+	// column is the column name of the field, defaulting to the lower-cased field name
+	column string
+
+	// columnOpts are the options following the column name in the db tag
+	columnOpts []string
+
+	// opts are the options of the patcher tag
+	opts []string
+
+	// prefix is the column prefix set with the prefix option
+	prefix string
+
+	// hasPrefix is true if the prefix option is set
+	hasPrefix bool
+}
+
+// newStructField parses the tags of the field of the struct
+func newStructField(st *types.Struct, i int) structField {
+	v := st.Field(i)
+	tag := reflect.StructTag(st.Tag(i))
+
+	f := structField{
+		v:    v,
+		name: v.Name(),
+	}
+
+	dbTag := tag.Get(patcher.DefaultDbTagName)
+	f.tag, _, _ = strings.Cut(dbTag, patcher.TagOptSeparator)
+	f.column = f.tag
+	if dbTag == "" {
+		f.column = strings.ToLower(f.name)
+	}
+
+	if _, opts, found := strings.Cut(dbTag, patcher.TagOptSeparator); found {
+		f.columnOpts = strings.Split(opts, patcher.TagOptSeparator)
+	}
+
+	if opts, ok := tag.Lookup(patcher.TagOptsName); ok {
+		f.opts = strings.Split(opts, patcher.TagOptSeparator)
+	}
+
+	for _, opt := range f.opts {
+		if key, value, found := strings.Cut(opt, patcher.TagOptValueSep); found && key == patcher.TagOptPrefix {
+			f.prefix, f.hasPrefix = value, true
+			break
+		}
+	}
+
+	return f
+}
+
+// has checks if the patcher tag of the field contains the option
+func (f structField) has(opt string) bool {
+	return slices.Contains(f.opts, opt)
+}
+
+// hasColumnOpt checks if the db tag of the field contains the option
+func (f structField) hasColumnOpt(opt string) bool {
+	return slices.Contains(f.columnOpts, opt)
+}
+
+// isPrimaryKey checks if the field is tagged as a primary key
+func (f structField) isPrimaryKey() bool {
+	return f.hasColumnOpt(patcher.DBTagPrimaryKey)
+}
+
+// isPointer returns the pointer type if the type is a pointer
+func isPointer(typ types.Type) (*types.Pointer, bool) {
+	ptr, ok := typ.Underlying().(*types.Pointer)
+	return ptr, ok
+}
+
+// derefType returns the element type of a pointer type
+func derefType(typ types.Type) types.Type {
+	if ptr, ok := isPointer(typ); ok {
+		return ptr.Elem()
+	}
+
+	return typ
+}
+
+// isValidType checks if the type can be stored as a database field, matching patcher.IsValidType
+func isValidType(typ types.Type) bool {
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&(types.IsBoolean|types.IsInteger|types.IsFloat|types.IsString) != 0
+	case *types.Struct, *types.Pointer:
+		return true
+	default:
+		return false
+	}
+}
+
+// isPatcherType checks if the type is the named type of the patcher package
+func isPatcherType(typ types.Type, name string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == patcherPath && obj.Name() == name
+}
+
+// implementsValuer checks if the method set of the type contains the Value method of driver.Valuer
+func implementsValuer(typ types.Type) bool {
+	sel := types.NewMethodSet(typ).Lookup(nil, "Value")
+	if sel == nil {
+		return false
+	}
+
+	sig, ok := sel.Type().(*types.Signature)
+	if !ok || sig.Params().Len() != 0 || sig.Results().Len() != 2 {
+		return false
+	}
+
+	value, ok := sig.Results().At(0).Type().(*types.Named)
+	if !ok || value.Obj().Pkg() == nil || value.Obj().Pkg().Path() != "database/sql/driver" || value.Obj().Name() != "Value" {
+		return false
+	}
+
+	return types.Identical(sig.Results().At(1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// isStdlib checks if the import path is of a standard library package
+func isStdlib(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// lastPathElem returns the last element of the import path
+func lastPathElem(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
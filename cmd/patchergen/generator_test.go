@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/tools/go/packages"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+type generatorSuite struct {
+	suite.Suite
+
+	models      *packages.Package
+	unsupported *packages.Package
+}
+
+func TestGeneratorSuite(t *testing.T) {
+	suite.Run(t, new(generatorSuite))
+}
+
+func (s *generatorSuite) SetupSuite() {
+	var err error
+
+	s.models, err = loadPackage("./testdata/models")
+	s.Require().NoError(err)
+
+	s.unsupported, err = loadPackage("./testdata/unsupported")
+	s.Require().NoError(err)
+}
+
+func (s *generatorSuite) TestGenerate_Golden() {
+	src, err := generate(s.models.Types, nil)
+	s.Require().NoError(err)
+
+	golden := filepath.Join("testdata", "models", "patcher_gen.go.golden")
+	if *update {
+		s.Require().NoError(os.WriteFile(golden, src, 0o600))
+	}
+
+	expected, err := os.ReadFile(golden)
+	s.Require().NoError(err)
+	s.Require().Equal(string(expected), string(src))
+}
+
+func (s *generatorSuite) TestGenerate_NamedTypes() {
+	src, err := generate(s.models.Types, []string{"Address", "Untagged"})
+	s.Require().NoError(err)
+
+	s.Require().Contains(string(src), "func (v Address) PatchFields() ([]string, []any) {")
+	s.Require().Contains(string(src), "func (o *Untagged) LoadDiffFrom(n *Untagged) {")
+	s.Require().NotContains(string(src), "func (v User) PatchFields()")
+}
+
+func (s *generatorSuite) TestGenerate_Errors() {
+	tests := []struct {
+		name     string
+		pkg      *packages.Package
+		typeName string
+		err      error
+		msg      string
+	}{
+		{
+			name:     "Unknown type",
+			pkg:      s.models,
+			typeName: "Missing",
+			err:      errInvalidStruct,
+			msg:      "Missing is not a struct type",
+		},
+		{
+			name:     "Not a struct",
+			pkg:      s.models,
+			typeName: "Status",
+			err:      errInvalidStruct,
+			msg:      "Status is not a struct type",
+		},
+		{
+			name:     "Version option",
+			pkg:      s.unsupported,
+			typeName: "Versioned",
+			err:      errUnsupportedField,
+			msg:      "Versioned.Version is tagged with the version option",
+		},
+		{
+			name:     "Increment option",
+			pkg:      s.unsupported,
+			typeName: "Counter",
+			err:      errUnsupportedField,
+			msg:      "Counter.Total is tagged with the increment option",
+		},
+		{
+			name:     "JSON option",
+			pkg:      s.unsupported,
+			typeName: "Document",
+			err:      errUnsupportedField,
+			msg:      "Document.Data is tagged with the json option",
+		},
+		{
+			name:     "Embedded non-struct",
+			pkg:      s.unsupported,
+			typeName: "Embedded",
+			err:      errUnsupportedField,
+			msg:      "embedded field Embedded.Name is not a struct",
+		},
+		{
+			name:     "Incomparable primary key",
+			pkg:      s.unsupported,
+			typeName: "Incomparable",
+			err:      errUnsupportedField,
+			msg:      "Incomparable: ID: unsupported field: Key is not comparable",
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			src, err := generate(tt.pkg.Types, []string{tt.typeName})
+			s.Require().ErrorIs(err, tt.err)
+			s.Require().ErrorContains(err, tt.msg)
+			s.Require().Nil(src)
+		})
+	}
+}
+
+func TestIsStdlib(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"time", true},
+		{"database/sql", true},
+		{"github.com/jacobbrewer1/patcher", false},
+		{"example.com/models", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, isStdlib(tt.path))
+		})
+	}
+}
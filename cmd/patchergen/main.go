@@ -0,0 +1,88 @@
+// Command patchergen generates reflection-free patch, diff and insert builders for structs tagged with db and patcher
+// tags. The generated methods implement patcher.Patchable, patcher.DiffLoader and patcher.Insertable, which
+// patcher.NewSQLPatch, patcher.LoadDiff and inserter.NewBatch use automatically.
+//
+// Usage:
+//
+//	//go:generate go run github.com/jacobbrewer1/patcher/cmd/patchergen [-type User,Order] [-output patcher_gen.go] [package]
+//
+// Without the type flag, builders are generated for every struct in the package with at least one db or patcher tag.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const defaultOutput = "patcher_gen.go"
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of the struct types to generate builders for")
+	output := flag.String("output", defaultOutput, "name of the generated file, written to the package directory")
+	flag.Parse()
+
+	pattern := "."
+	if flag.NArg() > 0 {
+		pattern = flag.Arg(0)
+	}
+
+	if err := run(pattern, *typeNames, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "patchergen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(pattern, typeNames, output string) error {
+	pkg, err := loadPackage(pattern)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	if typeNames != "" {
+		names = strings.Split(typeNames, ",")
+	}
+
+	src, err := generate(pkg.Types, names)
+	if err != nil {
+		return err
+	}
+
+	if len(pkg.GoFiles) == 0 {
+		return fmt.Errorf("package %s has no go files", pkg.PkgPath)
+	}
+
+	return os.WriteFile(filepath.Join(filepath.Dir(pkg.GoFiles[0]), output), src, 0o600)
+}
+
+// loadPackage loads and type checks the package. Previously generated files are excluded with the build tag so that
+// stale builders do not prevent the package from being loaded. Dependencies are type checked from source rather than
+// export data, which keeps the command independent of the version of the Go toolchain.
+func loadPackage(pattern string) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps |
+			packages.NeedSyntax | packages.NeedTypes,
+		BuildFlags: []string{"-tags=" + buildTag},
+	}
+
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("load package: %w", err)
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected a single package matching %s, found %d", pattern, len(pkgs))
+	}
+
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, fmt.Errorf("load package %s: %v", pkg.PkgPath, pkg.Errors[0])
+	}
+
+	return pkg, nil
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"time"
+)
+
+type Status string
+
+type Money int64
+
+func (m *Money) Value() (driver.Value, error) {
+	return int64(*m), nil
+}
+
+type Audit struct {
+	CreatedBy string  `db:"created_by"`
+	UpdatedBy *string `db:"updated_by"`
+}
+
+type Address struct {
+	Street string `db:"street"`
+	City   string `db:"city"`
+}
+
+type Metadata struct {
+	Source string
+}
+
+type User struct {
+	ID        int            `db:"id,pk"`
+	Name      string         `db:"name"`
+	Email     *string        `db:"email"`
+	Status    Status         `db:"status" patcher:"omitempty"`
+	Nickname  *string        `db:"nickname" patcher:"omitempty"`
+	Phone     sql.NullString `db:"phone"`
+	Balance   Money          `db:"balance"`
+	Password  string         `db:"password" patcher:"-"`
+	Tags      []string       `db:"tags"`
+	LastLogin time.Time      `db:"last_login"`
+	Address   Address        `patcher:"prefix=address_"`
+	Metadata  Metadata       `db:"metadata"`
+	internal  string
+	Audit
+	*Extra
+}
+
+type Extra struct {
+	TenantID *int   `db:"tenant_id,pk"`
+	Notes    string `db:"notes"`
+}
+
+type Untagged struct {
+	Name string
+}
@@ -0,0 +1,29 @@
+package unsupported
+
+type Versioned struct {
+	ID      int `db:"id,pk"`
+	Version int `db:"version" patcher:"version"`
+}
+
+type Counter struct {
+	ID    int `db:"id,pk"`
+	Total int `db:"total" patcher:"increment"`
+}
+
+type Document struct {
+	Data map[string]any `db:"data" patcher:"json"`
+}
+
+type Embedded struct {
+	Name
+}
+
+type Name string
+
+type Incomparable struct {
+	ID Key `db:"id,pk"`
+}
+
+type Key struct {
+	Parts []string
+}
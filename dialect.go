@@ -0,0 +1,269 @@
+package patcher
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrTooManyParameters is returned when a statement binds more parameters than the SQL dialect allows
+var ErrTooManyParameters = errors.New("too many parameters for the SQL dialect")
+
+// Dialect describes the SQL syntax of a database. The built-in dialects are provided by SQLDialect, other databases
+// can be supported by implementing this interface and passing it to WithDialectImpl.
+type Dialect interface {
+	// Placeholder returns the parameter placeholder for the nth argument of a statement, starting from 1. Dialects
+	// returning "?" for every argument have their statements written unchanged.
+	Placeholder(n int) string
+
+	// QuoteIdentifier quotes the given table or column name
+	QuoteIdentifier(identifier string) string
+
+	// UpdateJoinStyle returns the way the joins of an UPDATE statement are written
+	UpdateJoinStyle() UpdateJoinStyle
+
+	// ReturningStyle returns the way the columns returned from an UPDATE statement are written
+	ReturningStyle() ReturningStyle
+
+	// UpdateLimitStyle returns the way the number of rows changed by an UPDATE statement is limited
+	UpdateLimitStyle() UpdateLimitStyle
+
+	// UpsertStyle returns the syntax used to insert a row or update it when it already exists
+	UpsertStyle() UpsertStyle
+
+	// MultiRowInsertStyle returns the syntax used to insert several rows with a single statement
+	MultiRowInsertStyle() MultiRowInsertStyle
+
+	// BackslashEscapes reports whether a backslash escapes the next character of a string literal and " delimits a
+	// string literal, as in MySQL. Otherwise quotes are only escaped by doubling them and " delimits an identifier.
+	BackslashEscapes() bool
+
+	// MaxBindParameters returns the maximum number of parameters a single statement can bind. A zero value means
+	// there is no limit.
+	MaxBindParameters() int
+}
+
+// UpsertStyle is the syntax used by a dialect to insert a row or update it when it already exists
+type UpsertStyle int
+
+const (
+	// UpsertUnsupported is used by dialects that cannot insert or update a row in a single statement
+	UpsertUnsupported UpsertStyle = iota
+
+	// UpsertOnDuplicateKey uses INSERT ... ON DUPLICATE KEY UPDATE, as in MySQL
+	UpsertOnDuplicateKey
+
+	// UpsertOnConflict uses INSERT ... ON CONFLICT DO UPDATE, as in PostgreSQL and SQLite
+	UpsertOnConflict
+
+	// UpsertMerge uses a MERGE statement, as in SQL Server and Oracle
+	UpsertMerge
+)
+
+// MultiRowInsertStyle is the syntax used by a dialect to insert several rows with a single statement
+type MultiRowInsertStyle int
+
+const (
+	// MultiRowInsertValues lists every row in the VALUES clause of the INSERT statement
+	MultiRowInsertValues MultiRowInsertStyle = iota
+
+	// MultiRowInsertAll inserts each row with an INTO clause of an INSERT ALL statement, as in Oracle
+	MultiRowInsertAll
+)
+
+// SQLDialect represents the built-in SQL dialects
+type SQLDialect int
+
+const (
+	// DialectMySQL uses ? for parameter placeholders (default)
+	DialectMySQL SQLDialect = iota
+	// DialectSQLite uses ? for parameter placeholders (same as MySQL)
+	DialectSQLite
+	// DialectPostgreSQL uses $1, $2, $3 for parameter placeholders
+	DialectPostgreSQL
+	// DialectSQLServer uses @p1, @p2, @p3 for parameter placeholders and brackets to quote identifiers
+	DialectSQLServer
+	// DialectOracle uses :1, :2, :3 for parameter placeholders
+	DialectOracle
+)
+
+// identifierQuotes returns the characters used to open and close a quoted identifier in the dialect
+func (d SQLDialect) identifierQuotes() (open, closing string) {
+	switch d {
+	case DialectMySQL:
+		return "`", "`"
+	case DialectSQLServer:
+		return "[", "]"
+	default:
+		return `"`, `"`
+	}
+}
+
+// QuoteIdentifier quotes the given identifier for the dialect. MySQL uses backticks, SQL Server uses brackets,
+// PostgreSQL, SQLite and Oracle use double quotes.
+//
+// Qualified names such as "schema.table" are split on the dot and each part is quoted separately. Parts that are
+// already quoted are left untouched, and any closing quote character inside a part is escaped by doubling it.
+func (d SQLDialect) QuoteIdentifier(identifier string) string {
+	open, closing := d.identifierQuotes()
+
+	if !strings.Contains(identifier, ".") && !strings.Contains(identifier, open) &&
+		!strings.Contains(identifier, closing) {
+		// Fast path for the common case of a plain identifier
+		return open + identifier + closing
+	}
+
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		if len(part) >= 2 && strings.HasPrefix(part, open) && strings.HasSuffix(part, closing) {
+			continue
+		}
+
+		parts[i] = open + strings.ReplaceAll(part, closing, closing+closing) + closing
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// Placeholder returns the parameter placeholder for the nth argument of a statement in the dialect
+func (d SQLDialect) Placeholder(n int) string {
+	switch d {
+	case DialectPostgreSQL:
+		return "$" + strconv.Itoa(n)
+	case DialectSQLServer:
+		return "@p" + strconv.Itoa(n)
+	case DialectOracle:
+		return ":" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+// UpsertStyle returns the syntax used to insert a row or update it when it already exists in the dialect
+func (d SQLDialect) UpsertStyle() UpsertStyle {
+	switch d {
+	case DialectMySQL:
+		return UpsertOnDuplicateKey
+	case DialectPostgreSQL, DialectSQLite:
+		return UpsertOnConflict
+	case DialectSQLServer, DialectOracle:
+		return UpsertMerge
+	default:
+		return UpsertUnsupported
+	}
+}
+
+// MultiRowInsertStyle returns the syntax used to insert several rows with a single statement in the dialect
+func (d SQLDialect) MultiRowInsertStyle() MultiRowInsertStyle {
+	if d == DialectOracle {
+		return MultiRowInsertAll
+	}
+
+	return MultiRowInsertValues
+}
+
+// BackslashEscapes reports whether a backslash escapes the next character of a string literal and " delimits a string
+// literal in the dialect. This is only the case for MySQL.
+func (d SQLDialect) BackslashEscapes() bool {
+	return d == DialectMySQL
+}
+
+// MaxBindParameters returns the maximum number of parameters a single statement can bind in the dialect
+func (d SQLDialect) MaxBindParameters() int {
+	switch d {
+	case DialectSQLite:
+		return 32766
+	case DialectSQLServer:
+		return 2100
+	default:
+		return 65535
+	}
+}
+
+// Rebind converts the ? parameter placeholders in the SQL string to the placeholders used by the dialect. See the
+// package level Rebind for the question marks that are not converted.
+func (d SQLDialect) Rebind(sqlStr string) string {
+	return Rebind(d, sqlStr)
+}
+
+// Rebind converts the ? parameter placeholders in the SQL string to the placeholders used by the dialect.
+//
+// Question marks inside string literals, quoted identifiers, comments and PostgreSQL dollar-quoted bodies are not
+// placeholders, nor are the PostgreSQL ?| and ?& operators. Write ?? for a literal question mark, such as the
+// PostgreSQL ? operator. Dialects using ? placeholders are returned unchanged.
+func Rebind(dialect Dialect, sqlStr string) string {
+	w := newPlaceholderWriter(dialect, len(sqlStr))
+	if !w.numbered {
+		// For MySQL and SQLite, return unchanged (they use ?)
+		return sqlStr
+	}
+
+	w.writeSQL(sqlStr)
+	return w.String()
+}
+
+// checkBindParameters returns ErrTooManyParameters when the number of arguments exceeds the limit of the dialect
+func checkBindParameters(dialect Dialect, args int) error {
+	if limit := dialect.MaxBindParameters(); limit > 0 && args > limit {
+		return fmt.Errorf("%w: %d parameters exceed the limit of %d", ErrTooManyParameters, args, limit)
+	}
+	return nil
+}
+
+// placeholderWriter builds a SQL statement, converting the ? parameter placeholders of every fragment written to the
+// placeholders of the dialect. Placeholders are numbered across all fragments as they are written, so the statement
+// does not need to be rebound once it is built.
+type placeholderWriter struct {
+	strings.Builder
+
+	dialect Dialect
+
+	// numbered determines whether the placeholders of the dialect differ from ?
+	numbered bool
+
+	// syntax determines how the quoted parts of the fragments are read
+	syntax sqlSyntax
+
+	// placeholders is the number of placeholders written so far
+	placeholders int
+}
+
+// newPlaceholderWriter returns a placeholderWriter for the dialect with the given capacity
+func newPlaceholderWriter(dialect Dialect, size int) *placeholderWriter {
+	w := &placeholderWriter{
+		dialect:  dialect,
+		numbered: dialect.Placeholder(1) != "?",
+		syntax:   dialectSyntax(dialect),
+	}
+	w.Grow(size)
+	return w
+}
+
+// writeSQL writes the SQL fragment, converting its ? placeholders for the dialect. Question marks that are not
+// placeholders, such as those inside string literals or comments, are left untouched and ?? is written as a literal
+// question mark.
+func (w *placeholderWriter) writeSQL(sqlStr string) {
+	if !w.numbered {
+		w.WriteString(sqlStr)
+		return
+	}
+
+	l := newSQLLexer(sqlStr, w.syntax)
+	for {
+		text, tok := l.next()
+		w.WriteString(text)
+
+		switch tok {
+		case tokenPlaceholder:
+			w.placeholders++
+			w.WriteString(w.dialect.Placeholder(w.placeholders))
+		case tokenEscapedMark:
+			w.WriteByte('?')
+		case tokenNumberedPlaceholder:
+			w.WriteString(l.token())
+		case tokenEnd:
+			return
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package patcher
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLDialect_QuoteIdentifier(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		dialect    SQLDialect
+		identifier string
+		expected   string
+	}{
+		{"MySQL column", DialectMySQL, "order", "`order`"},
+		{"MySQL schema table", DialectMySQL, "shop.order", "`shop`.`order`"},
+		{"MySQL escaped quote", DialectMySQL, "we`ird", "`we``ird`"},
+		{"PostgreSQL column", DialectPostgreSQL, "user", `"user"`},
+		{"PostgreSQL schema table", DialectPostgreSQL, "public.user", `"public"."user"`},
+		{"PostgreSQL already quoted", DialectPostgreSQL, `"public".user`, `"public"."user"`},
+		{"SQLite column", DialectSQLite, "group", `"group"`},
+		{"SQL Server column", DialectSQLServer, "order", "[order]"},
+		{"SQL Server schema table", DialectSQLServer, "dbo.user", "[dbo].[user]"},
+		{"SQL Server already quoted", DialectSQLServer, "[dbo].user", "[dbo].[user]"},
+		{"SQL Server escaped quote", DialectSQLServer, "we]ird", "[we]]ird]"},
+		{"Oracle column", DialectOracle, "level", `"level"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual := tt.dialect.QuoteIdentifier(tt.identifier)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestSQLDialect_Rebind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		dialect  SQLDialect
+		sqlStr   string
+		expected string
+	}{
+		{"MySQL", DialectMySQL, "a = ? AND b = ?", "a = ? AND b = ?"},
+		{"SQLite", DialectSQLite, "a = ? AND b = ?", "a = ? AND b = ?"},
+		{"PostgreSQL", DialectPostgreSQL, "a = ? AND b = ?", "a = $1 AND b = $2"},
+		{"SQL Server", DialectSQLServer, "a = ? AND b = ?", "a = @p1 AND b = @p2"},
+		{"Oracle", DialectOracle, "a = ? AND b = ?", "a = :1 AND b = :2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual := tt.dialect.Rebind(tt.sqlStr)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestGenerateSQL_Dialects(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID      int    `db:"id,pk"`
+		Name    string `db:"name"`
+		Version int    `db:"version" patcher:"version"`
+	}
+
+	teams := &joinStringOption{join: "INNER JOIN teams t ON t.id = users.team_id AND t.name = ?", args: []any{"team"}}
+
+	tests := []struct {
+		name     string
+		dialect  SQLDialect
+		opts     []PatchOpt
+		wantSQL  string
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			name:    "SQL Server",
+			dialect: DialectSQLServer,
+			opts:    []PatchOpt{WithQuotedIdentifiers(true)},
+			wantSQL: "UPDATE [users]\nSET [name] = @p1, [version] = [version] + 1\nWHERE (1=1)\nAND (\n[id] = @p2\n)\n" +
+				"AND [version] = @p3",
+			wantArgs: []any{"John", 1, 3},
+		},
+		{
+			name:    "SQL Server join, returning and limit",
+			dialect: DialectSQLServer,
+			opts:    []PatchOpt{WithJoin(teams), WithWhereStr("users.age > ?", 18), WithReturning("id", "name"), WithLimit(10)},
+			wantSQL: "UPDATE TOP (10) users\n" +
+				"SET name = @p1, version = version + 1\n" +
+				"OUTPUT INSERTED.id, INSERTED.name\n" +
+				"FROM users\n" +
+				"INNER JOIN teams t ON t.id = users.team_id AND t.name = @p2\n" +
+				"WHERE (1=1)\nAND (\nusers.age > @p3\n)\nAND version = @p4",
+			wantArgs: []any{"John", "team", 18, 3},
+		},
+		{
+			name:    "Oracle",
+			dialect: DialectOracle,
+			opts:    []PatchOpt{WithQuotedIdentifiers(true), WithLimit(1)},
+			wantSQL: "UPDATE \"users\"\nSET \"name\" = :1, \"version\" = \"version\" + 1\nWHERE (1=1)\nAND (\n\"id\" = :2\n)\n" +
+				"AND \"version\" = :3\nAND ROWNUM <= 1",
+			wantArgs: []any{"John", 1, 3},
+		},
+		{
+			name:    "Oracle join",
+			dialect: DialectOracle,
+			opts:    []PatchOpt{WithJoin(teams)},
+			wantErr: ErrUnsupportedJoin,
+		},
+		{
+			name:    "Oracle returning",
+			dialect: DialectOracle,
+			opts:    []PatchOpt{WithReturning("id")},
+			wantErr: ErrReturningUnsupported,
+		},
+		{
+			name:     "MySQL limit",
+			dialect:  DialectMySQL,
+			opts:     []PatchOpt{WithLimit(5)},
+			wantSQL:  "UPDATE users\nSET name = ?, version = version + 1\nWHERE (1=1)\nAND (\nid = ?\n)\nAND version = ?\nLIMIT 5",
+			wantArgs: []any{"John", 1, 3},
+		},
+		{
+			name:    "MySQL limit with join",
+			dialect: DialectMySQL,
+			opts:    []PatchOpt{WithJoin(teams), WithLimit(5)},
+			wantErr: ErrLimitUnsupported,
+		},
+		{
+			name:    "PostgreSQL limit",
+			dialect: DialectPostgreSQL,
+			opts:    []PatchOpt{WithLimit(5)},
+			wantErr: ErrLimitUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]PatchOpt{WithTable("users"), WithDialect(tt.dialect)}, tt.opts...)
+			sqlStr, args, err := NewSQLPatch(&user{ID: 1, Name: "John", Version: 3}, opts...).GenerateSQL()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSQL, sqlStr)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+// customDialect is a dialect implemented outside of the built-in dialects, using PostgreSQL syntax with named
+// placeholders and a configurable parameter limit
+type customDialect struct {
+	SQLDialect
+
+	maxParameters int
+}
+
+func (d customDialect) Placeholder(n int) string {
+	return ":p" + strconv.Itoa(n)
+}
+
+func (d customDialect) MaxBindParameters() int {
+	return d.maxParameters
+}
+
+// topDialect is a custom dialect using PostgreSQL syntax that limits updates with UPDATE TOP
+type topDialect struct {
+	SQLDialect
+}
+
+func (topDialect) UpdateLimitStyle() UpdateLimitStyle {
+	return UpdateLimitTop
+}
+
+func TestWithDialectImpl(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	teams := &joinStringOption{join: "INNER JOIN teams t ON t.id = users.team_id AND t.name = ?", args: []any{"team"}}
+
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		opts     []PatchOpt
+		wantSQL  string
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			name:     "Custom placeholders",
+			dialect:  customDialect{SQLDialect: DialectPostgreSQL},
+			opts:     []PatchOpt{WithQuotedIdentifiers(true)},
+			wantSQL:  "UPDATE \"users\"\nSET \"name\" = :p1\nWHERE (1=1)\nAND (\n\"id\" = :p2\n)",
+			wantArgs: []any{"John", 1},
+		},
+		{
+			name:    "Join and returning style",
+			dialect: customDialect{SQLDialect: DialectPostgreSQL},
+			opts:    []PatchOpt{WithJoin(teams), WithReturning("id")},
+			wantSQL: "UPDATE users\nSET name = :p1\nFROM teams t\nWHERE (1=1)\nAND (t.id = users.team_id AND t.name = :p2)\n" +
+				"AND (\nid = :p3\n)\nRETURNING id",
+			wantArgs: []any{"John", "team", 1},
+		},
+		{
+			name:    "Too many parameters",
+			dialect: customDialect{SQLDialect: DialectPostgreSQL, maxParameters: 1},
+			wantErr: ErrTooManyParameters,
+		},
+		{
+			name:     "Limit style",
+			dialect:  topDialect{SQLDialect: DialectPostgreSQL},
+			opts:     []PatchOpt{WithLimit(5)},
+			wantSQL:  "UPDATE TOP (5) users\nSET name = $1\nWHERE (1=1)\nAND (\nid = $2\n)",
+			wantArgs: []any{"John", 1},
+		},
+		{
+			name:    "Limit unsupported",
+			dialect: customDialect{SQLDialect: DialectPostgreSQL},
+			opts:    []PatchOpt{WithLimit(5)},
+			wantErr: ErrLimitUnsupported,
+		},
+		{
+			name:     "Nil dialect",
+			dialect:  nil,
+			wantSQL:  "UPDATE users\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)",
+			wantArgs: []any{"John", 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]PatchOpt{WithTable("users"), WithDialectImpl(tt.dialect)}, tt.opts...)
+			sqlStr, args, err := NewSQLPatch(&user{ID: 1, Name: "John"}, opts...).GenerateSQL()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSQL, sqlStr)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
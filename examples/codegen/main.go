@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jacobbrewer1/patcher"
+)
+
+func main() {
+	email := "john@example.com"
+	user := User{
+		ID:    1,
+		Name:  "John",
+		Email: &email,
+	}
+
+	// User implements patcher.Patchable through the generated builders in patcher_gen.go, so the patch is built
+	// without reflection.
+	sqlStr, args, err := patcher.GenerateSQL(user, patcher.WithTable("users"))
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(sqlStr)
+	fmt.Println(args)
+
+	// Output:
+	// UPDATE users
+	// SET name = ?, email = ?
+	// WHERE (1=1)
+	// AND (
+	// id = ?
+	// )
+	// [John john@example.com 1]
+}
@@ -0,0 +1,13 @@
+package main
+
+import "time"
+
+//go:generate go run github.com/jacobbrewer1/patcher/cmd/patchergen -type User
+
+type User struct {
+	ID        int        `db:"id,pk"`
+	Name      string     `db:"name"`
+	Email     *string    `db:"email"`
+	Age       int        `db:"age"`
+	LastLogin *time.Time `db:"last_login"`
+}
@@ -0,0 +1,88 @@
+// Code generated by patchergen. DO NOT EDIT.
+
+//go:build !patchergen
+
+package main
+
+import (
+	"github.com/jacobbrewer1/patcher"
+)
+
+var (
+	_ patcher.Patchable        = (*User)(nil)
+	_ patcher.DiffLoader[User] = (*User)(nil)
+	_ patcher.Insertable       = (*User)(nil)
+)
+
+// PatchFields returns the columns and arguments of the fields of User to set in a patch.
+func (v User) PatchFields() ([]string, []any) {
+	columns := make([]string, 0, 4)
+	args := make([]any, 0, 4)
+	if v.Name != "" {
+		columns = append(columns, "name")
+		args = append(args, v.Name)
+	}
+	if v.Email != nil {
+		columns = append(columns, "email")
+		args = append(args, *v.Email)
+	}
+	if v.Age != 0 {
+		columns = append(columns, "age")
+		args = append(args, v.Age)
+	}
+	if v.LastLogin != nil {
+		columns = append(columns, "last_login")
+		args = append(args, *v.LastLogin)
+	}
+	return columns, args
+}
+
+// PatchKeys returns the columns and values of the primary key fields of User.
+func (v User) PatchKeys() ([]string, []any) {
+	columns := make([]string, 0, 1)
+	values := make([]any, 0, 1)
+	columns = append(columns, "id")
+	if v.ID != 0 {
+		values = append(values, v.ID)
+	} else {
+		values = append(values, nil)
+	}
+	return columns, values
+}
+
+// LoadDiffFrom loads the non-zero fields of n into the User.
+func (o *User) LoadDiffFrom(n *User) {
+	if n.ID != 0 {
+		o.ID = n.ID
+	}
+	if n.Name != "" {
+		o.Name = n.Name
+	}
+	if n.Email != nil {
+		o.Email = n.Email
+	}
+	if n.Age != 0 {
+		o.Age = n.Age
+	}
+	if n.LastLogin != nil {
+		o.LastLogin = n.LastLogin
+	}
+}
+
+// InsertFields returns the columns and arguments of the fields of User to insert.
+func (v User) InsertFields() ([]string, []any) {
+	args := make([]any, 0, 4)
+	args = append(args, v.Name)
+	if v.Email != nil {
+		args = append(args, *v.Email)
+	} else {
+		args = append(args, nil)
+	}
+	args = append(args, v.Age)
+	if v.LastLogin != nil {
+		args = append(args, *v.LastLogin)
+	} else {
+		args = append(args, nil)
+	}
+	return []string{"name", "email", "age", "last_login"}, args
+}
@@ -0,0 +1,13 @@
+package patcher
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executor is the subset of database/sql behaviour used to execute statements. It is satisfied by *sql.DB, *sql.Tx
+// and *sql.Conn, which allows a patch to be performed inside an existing transaction, on a dedicated connection or
+// through any wrapper that instruments the database calls.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
@@ -0,0 +1,68 @@
+package patcher
+
+import "fmt"
+
+// Expression is a SQL expression used as the value of a SET clause, such as "login_count + ?". The expression may use
+// ? or $1, $2, $3 placeholders, which are numbered again when the SQL is generated.
+type Expression struct {
+	sql  string
+	args []any
+}
+
+// Expr creates a new SQL expression with the given arguments. The expression can be used as the value of a struct
+// field or passed to WithSet.
+//
+// Example:
+//
+//	patcher.Expr("login_count + ?", 1)
+func Expr(sqlStr string, args ...any) Expression {
+	return Expression{
+		sql:  sqlStr,
+		args: args,
+	}
+}
+
+// Expr returns the SQL expression and its arguments
+func (e Expression) Expr() (sqlStr string, args []any) {
+	return e.sql, e.args
+}
+
+// setExpression is a SET clause expression given with the WithSet option
+type setExpression struct {
+	column string
+	expr   Expression
+}
+
+// exprGen appends the SET clause for the column with the value of the expression. The $1, $2, $3 placeholders of the
+// expression are converted to ? placeholders, recording an error if the placeholders do not match the arguments.
+func (s *SQLPatch) exprGen(column string, expr Expression) {
+	sqlStr, args, err := normalizePlaceholders(expr.sql, expr.args, dialectSyntax(s.dialect))
+	if err != nil {
+		if s.genErr == nil {
+			s.genErr = fmt.Errorf("SET %s: %w", column, err)
+		}
+		return
+	}
+
+	s.fields = append(s.fields, s.quote(column)+" = "+sqlStr)
+	s.args = append(s.args, args...)
+	s.exprFields++
+}
+
+// setGen appends the SET clauses given with the WithSet option
+func (s *SQLPatch) setGen() {
+	for _, set := range s.sets {
+		s.exprGen(set.column, set.expr)
+	}
+}
+
+// hasSet checks if the column is set with the WithSet option, which takes precedence over the struct field
+func (s *SQLPatch) hasSet(column string) bool {
+	for _, set := range s.sets {
+		if set.column == column {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package patcher
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
+)
+
+const fieldMaskSeparator = "."
+
+// ErrInvalidFieldMask is returned when a field mask path does not map onto any field of the resource
+var ErrInvalidFieldMask = errors.New("invalid field mask")
+
+// resolveFieldMask resolves the field mask paths against the struct type. Each path is resolved into the dot
+// separated Go field names used to track fields while generating the patch, including the names of any embedded
+// structs that the field is promoted from.
+func (s *SQLPatch) resolveFieldMask(typeOf reflect.Type) error {
+	if s.fieldMask == nil || s.maskPaths != nil {
+		return nil
+	}
+
+	maskPaths := make(map[string]struct{}, len(s.fieldMask))
+	for _, path := range s.fieldMask {
+		resolved, err := s.resolveFieldPath(typeOf, strings.Split(path, fieldMaskSeparator), "")
+		if err != nil {
+			return fmt.Errorf("%w: path %q %w", ErrInvalidFieldMask, path, err)
+		}
+
+		maskPaths[resolved] = struct{}{}
+	}
+
+	s.maskPaths = maskPaths
+	return nil
+}
+
+// resolveFieldPath resolves the path segments through the (possibly nested) struct type. Only the fields of structs
+// flattened into their columns can be addressed, as any other struct is stored as a single column.
+func (s *SQLPatch) resolveFieldPath(typeOf reflect.Type, segments []string, prefix string) (string, error) {
+	field, name, ok := s.findMaskField(typeOf, segments[0])
+	if !ok {
+		return "", errors.New("does not map to any field")
+	}
+
+	path := prefix + name
+	if len(segments) == 1 {
+		return path, nil
+	}
+
+	if _, flatten := fieldmeta.FlattenPrefix(&field); !flatten {
+		return "", fmt.Errorf("does not map to a column, %s is stored as a single column", field.Name)
+	}
+
+	nestedType := field.Type
+	if nestedType.Kind() == reflect.Ptr {
+		nestedType = nestedType.Elem()
+	}
+
+	return s.resolveFieldPath(nestedType, segments[1:], path+fieldMaskSeparator)
+}
+
+// findMaskField finds the exported field matching the segment by its Go name, json name or column name. Fields
+// promoted from embedded structs are searched after the fields declared directly on the struct.
+func (s *SQLPatch) findMaskField(typeOf reflect.Type, segment string) (reflect.StructField, string, bool) {
+	for i := range typeOf.NumField() {
+		field := typeOf.Field(i)
+		if field.IsExported() && s.maskFieldMatches(&field, segment) {
+			return field, field.Name, true
+		}
+	}
+
+	for i := range typeOf.NumField() {
+		field := typeOf.Field(i)
+		if !field.Anonymous {
+			continue
+		}
+
+		// Only the fields of embedded structs flattened into their columns are promoted
+		if _, flatten := fieldmeta.FlattenPrefix(&field); !flatten {
+			continue
+		}
+
+		embeddedType := field.Type
+		if embeddedType.Kind() == reflect.Ptr {
+			embeddedType = embeddedType.Elem()
+		}
+
+		if promoted, name, ok := s.findMaskField(embeddedType, segment); ok {
+			return promoted, field.Name + fieldMaskSeparator + name, true
+		}
+	}
+
+	return reflect.StructField{}, "", false
+}
+
+// maskFieldMatches determines whether the field mask segment refers to the field
+func (s *SQLPatch) maskFieldMatches(field *reflect.StructField, segment string) bool {
+	if segment == field.Name || segment == getTag(field, s.tagName) {
+		return true
+	}
+
+	name, _, ok := jsonFieldName(field)
+	return ok && segment == name
+}
+
+// isMaskedOut determines whether the field at the given path is excluded by the field mask. A field is included
+// when its path, one of its ancestors or one of its descendants is listed in the mask.
+func (s *SQLPatch) isMaskedOut(path string) bool {
+	if s.maskPaths == nil {
+		return false
+	}
+
+	if _, ok := s.maskPaths[path]; ok {
+		return false
+	}
+
+	for maskPath := range s.maskPaths {
+		if strings.HasPrefix(path, maskPath+fieldMaskSeparator) || strings.HasPrefix(maskPath, path+fieldMaskSeparator) {
+			return false
+		}
+	}
+
+	return true
+}
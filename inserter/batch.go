@@ -1,13 +1,13 @@
 package inserter
 
 import (
-	"database/sql"
 	"errors"
 	"reflect"
 	"slices"
-	"strings"
+	"time"
 
 	"github.com/jacobbrewer1/patcher"
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
 )
 
 var (
@@ -31,8 +31,8 @@ type SQLBatch struct {
 	// args is the arguments to use in the SQL statement
 	args []any
 
-	// db is the database connection to use
-	db *sql.DB
+	// db is the executor used to run the SQL statement
+	db patcher.Executor
 
 	// tagName is the tag name to look for in the struct. This is an override from the default tag "db"
 	tagName string
@@ -50,6 +50,30 @@ type SQLBatch struct {
 
 	// includePrimaryKey determines whether the primary key should be included in the insert
 	includePrimaryKey bool
+
+	// dialect is the SQL dialect to use for parameter placeholders and identifier quoting
+	dialect patcher.Dialect
+
+	// quoteIdentifiers determines whether the table and column names should be quoted using the dialect's
+	// identifier quoting
+	quoteIdentifiers bool
+
+	// genErr is the first error encountered while generating the batch. It is returned when the SQL is generated.
+	genErr error
+
+	// clock returns the current time used for the fields tagged with autocreate and autoupdate
+	clock patcher.Clock
+
+	// timestampSource determines where the timestamps of the fields tagged with autocreate and autoupdate are
+	// generated
+	timestampSource patcher.TimestampSource
+
+	// valueExprs are the SQL expressions inserted for a field instead of a bound argument, keyed by field
+	valueExprs map[string]string
+
+	// timeout is the maximum duration a single execution of the batch is allowed to take. A zero value means no
+	// timeout is applied on top of the context provided by the caller.
+	timeout time.Duration
 }
 
 // newBatchDefaults returns a new SQLBatch with default values
@@ -61,6 +85,8 @@ func newBatchDefaults(opts ...BatchOpt) *SQLBatch {
 		tagName:           patcher.DefaultDbTagName,
 		table:             "",
 		includePrimaryKey: false,
+		valueExprs:        make(map[string]string),
+		dialect:           patcher.DialectMySQL,
 	}
 
 	for _, opt := range opts {
@@ -114,27 +140,44 @@ func (b *SQLBatch) validateSQLInsert() error {
 	}
 }
 
-func (b *SQLBatch) checkSkipField(field *reflect.StructField) bool {
-	return b.checkSkipTag(field) || b.checkPrimaryKey(field) || b.ignoredFieldsCheck(field)
+// quote quotes the identifier for the dialect if identifier quoting is enabled
+func (b *SQLBatch) quote(identifier string) string {
+	if !b.quoteIdentifiers {
+		return identifier
+	}
+
+	return b.dialect.QuoteIdentifier(identifier)
+}
+
+// usesDefaultFields determines whether the fields of the batch are selected using the default rules, allowing the
+// generated insert builders of the resources to be used in place of reflection.
+func (b *SQLBatch) usesDefaultFields() bool {
+	return b.tagName == patcher.DefaultDbTagName &&
+		!b.includePrimaryKey &&
+		len(b.ignoreFields) == 0 &&
+		b.ignoreFieldsFunc == nil
 }
 
-func (b *SQLBatch) checkSkipTag(field *reflect.StructField) bool {
-	val, ok := field.Tag.Lookup(patcher.TagOptsName)
-	if !ok {
-		return false
-	}
-	return slices.Contains(strings.Split(val, patcher.TagOptSeparator), patcher.TagOptSkip)
+func (b *SQLBatch) checkSkipField(meta *fieldmeta.FieldMeta) bool {
+	return meta.Options.Has(fieldmeta.OptSkip) || b.checkPrimaryKey(meta) || b.ignoredFieldsCheck(&meta.Field)
 }
 
-func (b *SQLBatch) checkPrimaryKey(field *reflect.StructField) bool {
-	if b.includePrimaryKey {
-		return false
-	}
-	val, ok := field.Tag.Lookup(patcher.DefaultDbTagName)
-	if !ok {
-		return false
+// isTimestampField determines whether the field is tagged to be set to the current time on insert
+func (b *SQLBatch) isTimestampField(meta *fieldmeta.FieldMeta) bool {
+	return meta.Options.Has(fieldmeta.OptAutoCreate | fieldmeta.OptAutoUpdate)
+}
+
+// now returns the current time from the clock
+func (b *SQLBatch) now() time.Time {
+	if b.clock == nil {
+		return time.Now()
 	}
-	return slices.Contains(strings.Split(val, patcher.TagOptSeparator), patcher.DBTagPrimaryKey)
+
+	return b.clock()
+}
+
+func (b *SQLBatch) checkPrimaryKey(meta *fieldmeta.FieldMeta) bool {
+	return !b.includePrimaryKey && meta.ColumnOptions.Has(fieldmeta.OptPrimaryKey)
 }
 
 func (b *SQLBatch) ignoredFieldsCheck(field *reflect.StructField) bool {
@@ -2,6 +2,7 @@ package inserter
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/jacobbrewer1/patcher"
 )
@@ -25,10 +26,23 @@ func WithTable(table string) BatchOpt {
 // WithDB sets the database connection to use
 func WithDB(db *sql.DB) BatchOpt {
 	return func(b *SQLBatch) {
+		if db == nil {
+			// Avoid storing a typed nil in the Executor interface
+			b.db = nil
+			return
+		}
 		b.db = db
 	}
 }
 
+// WithExecutor sets the executor to use when performing the insert. This can be a *sql.DB, *sql.Tx, *sql.Conn or any
+// type implementing the patcher.Executor interface.
+func WithExecutor(exec patcher.Executor) BatchOpt {
+	return func(b *SQLBatch) {
+		b.db = exec
+	}
+}
+
 // WithIgnoreFields sets the fields to ignore when patching
 func WithIgnoreFields(fields ...string) BatchOpt {
 	return func(b *SQLBatch) {
@@ -49,3 +63,59 @@ func WithIncludePrimaryKey(includePrimaryKey bool) BatchOpt {
 		b.includePrimaryKey = includePrimaryKey
 	}
 }
+
+// WithDialect sets the SQL dialect to use for parameter placeholders and identifier quoting.
+// Default is patcher.DialectMySQL which uses ? placeholders. With patcher.DialectOracle, batches of several rows are
+// inserted with INSERT ALL, as Oracle does not support multiple rows in a VALUES clause.
+func WithDialect(dialect patcher.SQLDialect) BatchOpt {
+	return func(b *SQLBatch) {
+		b.dialect = dialect
+	}
+}
+
+// WithDialectImpl sets the SQL dialect to use for a database that is not covered by the built-in dialects. The
+// dialect determines the parameter placeholders, the identifier quoting, how several rows are inserted and the maximum
+// number of parameters of the statement. A nil dialect uses the default patcher.DialectMySQL.
+func WithDialectImpl(dialect patcher.Dialect) BatchOpt {
+	return func(b *SQLBatch) {
+		if dialect == nil {
+			dialect = patcher.DialectMySQL
+		}
+		b.dialect = dialect
+	}
+}
+
+// WithQuotedIdentifiers sets whether the table and column names should be quoted using the identifier quoting of the
+// dialect set with WithDialect or WithDialectImpl.
+func WithQuotedIdentifiers(quoteIdentifiers bool) BatchOpt {
+	return func(b *SQLBatch) {
+		b.quoteIdentifiers = quoteIdentifiers
+	}
+}
+
+// WithTimeout sets the maximum duration a single execution of the batch is allowed to take.
+//
+// The timeout is applied on top of the context passed to PerformContext. A zero or negative duration disables
+// the timeout.
+func WithTimeout(timeout time.Duration) BatchOpt {
+	return func(b *SQLBatch) {
+		b.timeout = timeout
+	}
+}
+
+// WithClock sets the clock used to generate the timestamps of fields tagged with autocreate and autoupdate. Default
+// is time.Now.
+func WithClock(clock patcher.Clock) BatchOpt {
+	return func(b *SQLBatch) {
+		b.clock = clock
+	}
+}
+
+// WithTimestampSource sets where the timestamps of fields tagged with autocreate and autoupdate are generated.
+// Default is patcher.TimestampSourceClock, which binds the time from the clock as an argument for each row.
+// patcher.TimestampSourceDatabase inserts CURRENT_TIMESTAMP instead.
+func WithTimestampSource(source patcher.TimestampSource) BatchOpt {
+	return func(b *SQLBatch) {
+		b.timestampSource = source
+	}
+}
@@ -1,14 +1,22 @@
 package inserter
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jacobbrewer1/patcher"
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
 )
 
+// NewBatch creates a new SQLBatch for inserting the resources. Resources implementing patcher.Insertable, such as those
+// with builders generated by patchergen, are processed without reflection when the options do not change which fields
+// are inserted.
 func NewBatch(resources []any, opts ...BatchOpt) *SQLBatch {
 	b := newBatchDefaults(opts...)
 	for _, opt := range opts {
@@ -21,7 +29,20 @@ func NewBatch(resources []any, opts ...BatchOpt) *SQLBatch {
 func (b *SQLBatch) genBatch(resources []any) {
 	uniqueFields := make(map[string]struct{})
 
+	// unsetFields tracks the Optional fields that have not been set on any of the resources, argFields tracks the
+	// field of each argument so that those fields can be removed from the batch.
+	unsetFields := make(map[string]bool)
+	argFields := make([]string, 0)
+
+	// All rows of the batch share the same timestamp
+	now := b.now()
+
 	for _, r := range resources {
+		if insertable, ok := r.(patcher.Insertable); ok && b.usesDefaultFields() {
+			b.insertableGen(insertable, uniqueFields, &argFields)
+			continue
+		}
+
 		t := reflect.TypeOf(r)
 		if t.Kind() == reflect.Ptr {
 			t = t.Elem()
@@ -35,26 +56,41 @@ func (b *SQLBatch) genBatch(resources []any) {
 			v = v.Elem()
 		}
 
-		for i := range t.NumField() {
-			f := t.Field(i)
+		fields := fieldmeta.TypeFields(t, b.tagName)
+		for i := range fields {
+			meta := &fields[i]
 			fVal := v.Field(i)
 
-			if !patcher.IsValidType(fVal) || !f.IsExported() || b.checkSkipField(&f) {
+			if (!patcher.IsValidType(fVal) && !meta.Options.Has(fieldmeta.OptJSON)) || !meta.Field.IsExported() || b.checkSkipField(meta) {
 				continue
 			}
 
-			tag := f.Tag.Get(b.tagName)
+			tag := meta.Tag
 			if tag == patcher.TagOptSkip {
 				continue
 			}
 
 			if tag == "" {
-				tag = f.Name
-			} else {
-				tag = strings.Split(tag, patcher.TagOptSeparator)[0]
+				tag = meta.Field.Name
+			}
+
+			if b.isTimestampField(meta) {
+				b.timestampGen(tag, now, &argFields)
+				if _, ok := uniqueFields[tag]; !ok {
+					b.fields = append(b.fields, tag)
+					uniqueFields[tag] = struct{}{}
+				}
+				continue
+			}
+
+			if meta.Optional {
+				opt, _ := fVal.Interface().(patcher.OptionalValue)
+				unset, seen := unsetFields[tag]
+				unsetFields[tag] = !opt.IsSet() && (!seen || unset)
 			}
 
-			b.args = append(b.args, b.getFieldValue(fVal, &f))
+			b.args = append(b.args, b.getFieldValue(fVal, meta))
+			argFields = append(argFields, tag)
 
 			if _, ok := uniqueFields[tag]; ok {
 				continue
@@ -64,38 +100,186 @@ func (b *SQLBatch) genBatch(resources []any) {
 			uniqueFields[tag] = struct{}{}
 		}
 	}
+
+	b.removeUnsetFields(unsetFields, argFields)
+}
+
+// insertableGen adds the fields of the resource from its generated insert builder
+func (b *SQLBatch) insertableGen(resource patcher.Insertable, uniqueFields map[string]struct{}, argFields *[]string) {
+	columns, args := resource.InsertFields()
+	for i, column := range columns {
+		b.args = append(b.args, args[i])
+		*argFields = append(*argFields, column)
+
+		if _, ok := uniqueFields[column]; ok {
+			continue
+		}
+
+		b.fields = append(b.fields, column)
+		uniqueFields[column] = struct{}{}
+	}
+}
+
+// timestampGen adds the current time for the field tagged with autocreate or autoupdate. When the database generates
+// the timestamps, the field is inserted as CURRENT_TIMESTAMP without an argument.
+func (b *SQLBatch) timestampGen(tag string, now time.Time, argFields *[]string) {
+	if b.timestampSource == patcher.TimestampSourceDatabase {
+		b.valueExprs[tag] = patcher.CurrentTimestamp
+		return
+	}
+
+	b.args = append(b.args, now)
+	*argFields = append(*argFields, tag)
+}
+
+// removeUnsetFields removes the Optional fields that are unset on every resource from the batch. Optional fields that
+// are set on some of the resources are inserted as NULL for the resources where they are unset.
+func (b *SQLBatch) removeUnsetFields(unsetFields map[string]bool, argFields []string) {
+	b.fields = slices.DeleteFunc(b.fields, func(field string) bool {
+		return unsetFields[field]
+	})
+
+	args := b.args[:0]
+	for i, arg := range b.args {
+		if !unsetFields[argFields[i]] {
+			args = append(args, arg)
+		}
+	}
+	b.args = args
 }
 
-func (b *SQLBatch) getFieldValue(v reflect.Value, f *reflect.StructField) any {
-	if f.Type.Kind() == reflect.Ptr && v.IsNil() {
+func (b *SQLBatch) getFieldValue(v reflect.Value, meta *fieldmeta.FieldMeta) any {
+	if meta.Optional {
+		opt, _ := v.Interface().(patcher.OptionalValue)
+		if opt.AnyValue() == nil {
+			return nil
+		}
+
+		value := reflect.ValueOf(opt.AnyValue())
+		if meta.Options.Has(fieldmeta.OptJSON) {
+			return b.getJSONValue(value, meta)
+		}
+
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return nil
+			}
+			return value.Elem().Interface()
+		}
+
+		return value.Interface()
+	}
+
+	if meta.Options.Has(fieldmeta.OptJSON) {
+		return b.getJSONValue(v, meta)
+	}
+
+	if meta.Field.Type.Kind() == reflect.Ptr && v.IsNil() {
 		return nil
-	} else if f.Type.Kind() == reflect.Ptr {
+	} else if meta.Field.Type.Kind() == reflect.Ptr {
 		return v.Elem().Interface()
 	}
 
 	return v.Interface()
 }
 
+// getJSONValue encodes the field value as JSON. Nil pointers, maps and slices are inserted as NULL. Encoding errors
+// are recorded and returned when the SQL is generated.
+func (b *SQLBatch) getJSONValue(v reflect.Value, meta *fieldmeta.FieldMeta) any {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		return nil
+	}
+
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		if b.genErr == nil {
+			b.genErr = fmt.Errorf("encode json field %s: %w", meta.Field.Name, err)
+		}
+		return nil
+	}
+
+	return string(encoded)
+}
+
 func (b *SQLBatch) GenerateSQL() (sqlStr string, args []any, err error) {
+	if b.genErr != nil {
+		return "", nil, fmt.Errorf("generate batch: %w", b.genErr)
+	}
+
 	if err := b.validateSQLGen(); err != nil {
 		return "", nil, err
 	}
 
+	values := make([]string, len(b.fields))
+	numArgs := 0
+	for i, field := range b.fields {
+		if expr, ok := b.valueExprs[field]; ok {
+			values[i] = expr
+			continue
+		}
+
+		values[i] = "?"
+		numArgs++
+	}
+
+	placeholder := "(" + strings.Join(values, ", ") + ")"
+	rows := len(b.args) / max(numArgs, 1)
+
+	if limit := b.dialect.MaxBindParameters(); limit > 0 && len(b.args) > limit {
+		return "", nil, fmt.Errorf("%w: %d parameters exceed the limit of %d", patcher.ErrTooManyParameters, len(b.args), limit)
+	}
+
+	columns := new(strings.Builder)
+	columns.WriteString(b.quote(b.table))
+	columns.WriteString(" (")
+	for i, field := range b.fields {
+		if i > 0 {
+			columns.WriteString(", ")
+		}
+		columns.WriteString(b.quote(field))
+	}
+	columns.WriteString(")")
+
 	sqlBuilder := new(strings.Builder)
+	sqlBuilder.Grow(64 + rows*(columns.Len()+len(placeholder)+16))
+
+	// Oracle does not support inserting multiple rows with a single VALUES clause
+	if b.dialect.MultiRowInsertStyle() == patcher.MultiRowInsertAll && rows > 1 {
+		sqlBuilder.WriteString("INSERT ALL\n")
+		for range rows {
+			sqlBuilder.WriteString("INTO ")
+			sqlBuilder.WriteString(columns.String())
+			sqlBuilder.WriteString(" VALUES ")
+			sqlBuilder.WriteString(placeholder)
+			sqlBuilder.WriteString("\n")
+		}
+		sqlBuilder.WriteString("SELECT 1 FROM DUAL")
+
+		return patcher.Rebind(b.dialect, sqlBuilder.String()), b.args, nil
+	}
+
 	sqlBuilder.WriteString("INSERT INTO ")
-	sqlBuilder.WriteString(b.table)
-	sqlBuilder.WriteString(" (")
-	sqlBuilder.WriteString(strings.Join(b.fields, ", "))
-	sqlBuilder.WriteString(") VALUES ")
+	sqlBuilder.WriteString(columns.String())
+	sqlBuilder.WriteString(" VALUES ")
 
-	placeholder := "(" + strings.Repeat("?, ", len(b.fields)-1) + "?)"
-	placeholders := strings.Repeat(placeholder+", ", len(b.args)/len(b.fields))
-	sqlBuilder.WriteString(placeholders[:len(placeholders)-2])
+	for i := range rows {
+		if i > 0 {
+			sqlBuilder.WriteString(", ")
+		}
+		sqlBuilder.WriteString(placeholder)
+	}
 
-	return sqlBuilder.String(), b.args, nil
+	return patcher.Rebind(b.dialect, sqlBuilder.String()), b.args, nil
 }
 
+// Perform executes the insert statement for the batch.
 func (b *SQLBatch) Perform() (sql.Result, error) {
+	return b.PerformContext(context.Background())
+}
+
+// PerformContext executes the insert statement for the batch using the provided context. If a timeout has been
+// configured with WithTimeout, it is applied on top of the given context.
+func (b *SQLBatch) PerformContext(ctx context.Context) (sql.Result, error) {
 	if err := b.validateSQLInsert(); err != nil {
 		return nil, fmt.Errorf("validate SQL generation: %w", err)
 	}
@@ -105,5 +289,11 @@ func (b *SQLBatch) Perform() (sql.Result, error) {
 		return nil, fmt.Errorf("generate SQL: %w", err)
 	}
 
-	return b.db.Exec(sqlStr, args...)
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	return b.db.ExecContext(ctx, sqlStr, args...)
 }
@@ -1,8 +1,11 @@
 package inserter
 
 import (
+	"context"
+	"database/sql/driver"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jacobbrewer1/patcher"
 	"github.com/stretchr/testify/mock"
@@ -488,3 +491,463 @@ func (s *generateSQLSuite) TestGenerateSQL_Success_IgnoredFieldsFunc() {
 	s.Equal("INSERT INTO temp (name) VALUES (?), (?), (?), (?), (?)", sql)
 	s.Len(args, 5)
 }
+
+func (s *generateSQLSuite) TestGenerateSQL_Success_QuotedIdentifiers() {
+	type temp struct {
+		ID    int    `db:"id"`
+		Order string `db:"order"`
+	}
+
+	resources := []any{
+		&temp{ID: 1, Order: "test"},
+		&temp{ID: 2, Order: "test2"},
+	}
+
+	b := NewBatch(resources, WithTable("shop.temp"), WithQuotedIdentifiers(true))
+
+	sql, args, err := b.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("INSERT INTO `shop`.`temp` (`id`, `order`) VALUES (?, ?), (?, ?)", sql)
+	s.Len(args, 4)
+}
+
+func (s *generateSQLSuite) TestGenerateSQL_Success_PostgreSQL_QuotedIdentifiers() {
+	type temp struct {
+		ID   int    `db:"id"`
+		User string `db:"user"`
+	}
+
+	resources := []any{
+		&temp{ID: 1, User: "test"},
+		&temp{ID: 2, User: "test2"},
+	}
+
+	b := NewBatch(resources, WithTable("temp"), WithDialect(patcher.DialectPostgreSQL), WithQuotedIdentifiers(true))
+
+	sql, args, err := b.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal(`INSERT INTO "temp" ("id", "user") VALUES ($1, $2), ($3, $4)`, sql)
+	s.Len(args, 4)
+}
+
+func (s *generateSQLSuite) TestGenerateSQL_Success_SQLServer_QuotedIdentifiers() {
+	type temp struct {
+		ID   int    `db:"id"`
+		User string `db:"user"`
+	}
+
+	resources := []any{
+		&temp{ID: 1, User: "test"},
+		&temp{ID: 2, User: "test2"},
+	}
+
+	b := NewBatch(resources, WithTable("dbo.temp"), WithDialect(patcher.DialectSQLServer), WithQuotedIdentifiers(true))
+
+	sql, args, err := b.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("INSERT INTO [dbo].[temp] ([id], [user]) VALUES (@p1, @p2), (@p3, @p4)", sql)
+	s.Equal([]any{1, "test", 2, "test2"}, args)
+}
+
+func (s *generateSQLSuite) TestGenerateSQL_Success_Oracle() {
+	type temp struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	sql, args, err := NewBatch([]any{&temp{ID: 1, Name: "test"}},
+		WithTable("temp"),
+		WithDialect(patcher.DialectOracle),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("INSERT INTO temp (id, name) VALUES (:1, :2)", sql)
+	s.Equal([]any{1, "test"}, args)
+}
+
+func (s *generateSQLSuite) TestGenerateSQL_Success_Oracle_MultipleRows() {
+	type temp struct {
+		ID        int       `db:"id"`
+		Name      string    `db:"name"`
+		CreatedAt time.Time `db:"created_at" patcher:"autocreate"`
+	}
+
+	resources := []any{
+		&temp{ID: 1, Name: "test"},
+		&temp{ID: 2, Name: "test2"},
+	}
+
+	sql, args, err := NewBatch(resources,
+		WithTable("temp"),
+		WithDialect(patcher.DialectOracle),
+		WithQuotedIdentifiers(true),
+		WithTimestampSource(patcher.TimestampSourceDatabase),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("INSERT ALL\n"+
+		`INTO "temp" ("id", "name", "created_at") VALUES (:1, :2, CURRENT_TIMESTAMP)`+"\n"+
+		`INTO "temp" ("id", "name", "created_at") VALUES (:3, :4, CURRENT_TIMESTAMP)`+"\n"+
+		"SELECT 1 FROM DUAL", sql)
+	s.Equal([]any{1, "test", 2, "test2"}, args)
+}
+
+// limitedDialect is a custom dialect using PostgreSQL syntax with a limit on the number of parameters
+type limitedDialect struct {
+	patcher.SQLDialect
+
+	maxParameters int
+}
+
+func (d limitedDialect) MaxBindParameters() int {
+	return d.maxParameters
+}
+
+// insertAllDialect is a custom dialect using PostgreSQL syntax that inserts several rows with INSERT ALL
+type insertAllDialect struct {
+	patcher.SQLDialect
+}
+
+func (insertAllDialect) MultiRowInsertStyle() patcher.MultiRowInsertStyle {
+	return patcher.MultiRowInsertAll
+}
+
+func (s *generateSQLSuite) TestGenerateSQL_DialectImpl() {
+	type temp struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	resources := []any{
+		&temp{ID: 1, Name: "test"},
+		&temp{ID: 2, Name: "test2"},
+	}
+
+	sql, args, err := NewBatch(resources,
+		WithTable("temp"),
+		WithDialectImpl(limitedDialect{SQLDialect: patcher.DialectPostgreSQL, maxParameters: 4}),
+		WithQuotedIdentifiers(true),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal(`INSERT INTO "temp" ("id", "name") VALUES ($1, $2), ($3, $4)`, sql)
+	s.Equal([]any{1, "test", 2, "test2"}, args)
+
+	_, _, err = NewBatch(resources,
+		WithTable("temp"),
+		WithDialectImpl(limitedDialect{SQLDialect: patcher.DialectPostgreSQL, maxParameters: 3}),
+	).GenerateSQL()
+	s.Require().ErrorIs(err, patcher.ErrTooManyParameters)
+
+	sql, args, err = NewBatch(resources,
+		WithTable("temp"),
+		WithDialectImpl(insertAllDialect{SQLDialect: patcher.DialectPostgreSQL}),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("INSERT ALL\nINTO temp (id, name) VALUES ($1, $2)\nINTO temp (id, name) VALUES ($3, $4)\nSELECT 1 FROM DUAL", sql)
+	s.Equal([]any{1, "test", 2, "test2"}, args)
+}
+
+func (s *generateSQLSuite) TestGenerateSQL_Success_JSON() {
+	type temp struct {
+		ID       int            `db:"id"`
+		Tags     []string       `db:"tags" patcher:"json"`
+		Metadata map[string]any `db:"metadata" patcher:"json"`
+		Ignored  []string       `db:"ignored"`
+	}
+
+	resources := []any{
+		&temp{ID: 1, Tags: []string{"a", "b"}, Metadata: map[string]any{"key": "value"}},
+		&temp{ID: 2},
+	}
+
+	b := NewBatch(resources, WithTable("temp"))
+
+	sql, args, err := b.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("INSERT INTO temp (id, tags, metadata) VALUES (?, ?, ?), (?, ?, ?)", sql)
+	s.Equal([]any{1, `["a","b"]`, `{"key":"value"}`, 2, nil, nil}, args)
+}
+
+func (s *generateSQLSuite) TestGenerateSQL_Failure_JSON() {
+	type temp struct {
+		ID      int            `db:"id"`
+		Invalid map[string]any `db:"invalid" patcher:"json"`
+	}
+
+	b := NewBatch([]any{&temp{ID: 1, Invalid: map[string]any{"fn": func() {}}}}, WithTable("temp"))
+
+	_, _, err := b.GenerateSQL()
+	s.Require().Error(err)
+}
+
+type performSuite struct {
+	suite.Suite
+}
+
+func TestPerformSuite(t *testing.T) {
+	suite.Run(t, new(performSuite))
+}
+
+func (s *performSuite) TestPerformContext_NoDatabaseConnection() {
+	type temp struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	b := NewBatch([]any{&temp{ID: 1, Name: "test"}}, WithTable("temp"))
+
+	res, err := b.PerformContext(context.Background())
+	s.Require().ErrorIs(err, ErrNoDatabaseConnection)
+	s.Nil(res)
+}
+
+func (s *performSuite) TestPerformContext_Executor() {
+	type temp struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	ctx := context.Background()
+
+	exec := patcher.NewMockExecutor(s.T())
+	exec.On("ExecContext", ctx, "INSERT INTO temp (id, name) VALUES (?, ?), (?, ?)", 1, "test", 2, "test2").
+		Return(driver.RowsAffected(2), nil)
+
+	b := NewBatch([]any{
+		&temp{ID: 1, Name: "test"},
+		&temp{ID: 2, Name: "test2"},
+	}, WithTable("temp"), WithExecutor(exec))
+
+	res, err := b.PerformContext(ctx)
+	s.Require().NoError(err)
+
+	affected, err := res.RowsAffected()
+	s.Require().NoError(err)
+	s.Equal(int64(2), affected)
+}
+
+func (s *performSuite) TestPerformContext_Timeout() {
+	type temp struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "caller")
+
+	exec := patcher.NewMockExecutor(s.T())
+	exec.On("ExecContext", mock.MatchedBy(func(execCtx context.Context) bool {
+		deadline, ok := execCtx.Deadline()
+		return ok && time.Until(deadline) <= time.Second && execCtx.Value(ctxKey{}) == "caller"
+	}), "INSERT INTO temp (id, name) VALUES (?, ?)", 1, "test").Return(driver.RowsAffected(1), nil)
+
+	_, err := NewBatch([]any{&temp{ID: 1, Name: "test"}},
+		WithTable("temp"),
+		WithExecutor(exec),
+		WithTimeout(time.Second),
+	).PerformContext(ctx)
+	s.Require().NoError(err)
+}
+
+func (s *performSuite) TestPerformContext_NoTimeout() {
+	type temp struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	exec := patcher.NewMockExecutor(s.T())
+	exec.On("ExecContext", mock.MatchedBy(func(execCtx context.Context) bool {
+		_, ok := execCtx.Deadline()
+		return !ok
+	}), "INSERT INTO temp (id, name) VALUES (?, ?)", 1, "test").Return(driver.RowsAffected(1), nil)
+
+	_, err := NewBatch([]any{&temp{ID: 1, Name: "test"}},
+		WithTable("temp"),
+		WithExecutor(exec),
+	).PerformContext(context.Background())
+	s.Require().NoError(err)
+}
+
+func (s *performSuite) TestWithTimeout() {
+	b := NewBatch(nil, WithTable("temp"), WithTimeout(5*time.Second))
+
+	s.Equal(5*time.Second, b.timeout)
+}
+
+type optionalSuite struct {
+	suite.Suite
+}
+
+func TestOptionalSuite(t *testing.T) {
+	suite.Run(t, new(optionalSuite))
+}
+
+func (s *optionalSuite) TestGenerateSQL() {
+	type temp struct {
+		ID    int                       `db:"id"`
+		Name  patcher.Optional[string]  `db:"name"`
+		Age   patcher.Optional[int]     `db:"age"`
+		Email patcher.Optional[string]  `db:"email"`
+		Score patcher.Optional[*string] `db:"score"`
+	}
+
+	b := NewBatch([]any{
+		&temp{ID: 1, Name: patcher.Some("test"), Age: patcher.Some(0)},
+		&temp{ID: 2, Name: patcher.Null[string]()},
+	}, WithTable("temp"))
+
+	sql, args, err := b.GenerateSQL()
+	s.Require().NoError(err)
+	s.Equal("INSERT INTO temp (id, name, age) VALUES (?, ?, ?), (?, ?, ?)", sql)
+	s.Equal([]any{1, "test", 0, 2, nil, nil}, args)
+}
+
+type timestampSuite struct {
+	suite.Suite
+
+	now time.Time
+}
+
+func TestTimestampSuite(t *testing.T) {
+	suite.Run(t, new(timestampSuite))
+}
+
+func (s *timestampSuite) SetupTest() {
+	s.now = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+}
+
+func (s *timestampSuite) clock() time.Time {
+	return s.now
+}
+
+type timestampTemp struct {
+	ID        int        `db:"id,pk"`
+	Name      string     `db:"name"`
+	CreatedAt time.Time  `db:"created_at" patcher:"autocreate"`
+	UpdatedAt *time.Time `db:"updated_at" patcher:"autoupdate"`
+}
+
+func (s *timestampSuite) TestGenerateSQL() {
+	b := NewBatch([]any{
+		&timestampTemp{ID: 1, Name: "test"},
+		&timestampTemp{ID: 2, Name: "test2", CreatedAt: s.now.Add(-time.Hour)},
+	}, WithTable("temp"), WithClock(s.clock))
+
+	sql, args, err := b.GenerateSQL()
+	s.Require().NoError(err)
+	s.Equal("INSERT INTO temp (name, created_at, updated_at) VALUES (?, ?, ?), (?, ?, ?)", sql)
+	s.Equal([]any{"test", s.now, s.now, "test2", s.now, s.now}, args)
+}
+
+func (s *timestampSuite) TestGenerateSQL_Database() {
+	b := NewBatch([]any{
+		&timestampTemp{ID: 1, Name: "test"},
+		&timestampTemp{ID: 2, Name: "test2"},
+	},
+		WithTable("temp"),
+		WithTimestampSource(patcher.TimestampSourceDatabase),
+		WithDialect(patcher.DialectPostgreSQL),
+	)
+
+	sql, args, err := b.GenerateSQL()
+	s.Require().NoError(err)
+	s.Equal("INSERT INTO temp (name, created_at, updated_at) VALUES ($1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP), ($2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)", sql)
+	s.Equal([]any{"test", "test2"}, args)
+}
+
+type insertableSuite struct {
+	suite.Suite
+}
+
+func TestInsertableSuite(t *testing.T) {
+	suite.Run(t, new(insertableSuite))
+}
+
+type insertableTemp struct {
+	ID    int     `db:"id,pk"`
+	Name  string  `db:"name"`
+	Email *string `db:"email"`
+	Age   int     `db:"age"`
+}
+
+// InsertFields is generated by patchergen for insertableTemp
+func (v insertableTemp) InsertFields() ([]string, []any) {
+	args := make([]any, 0, 3)
+	args = append(args, v.Name)
+	if v.Email != nil {
+		args = append(args, *v.Email)
+	} else {
+		args = append(args, nil)
+	}
+	args = append(args, v.Age)
+	return []string{"name", "email", "age"}, args
+}
+
+// reflectedTemp has the fields of insertableTemp without the generated builder
+type reflectedTemp insertableTemp
+
+func (s *insertableSuite) TestGenerateSQL_MatchesReflection() {
+	resources := []insertableTemp{
+		{ID: 1, Name: "test", Email: ptr("test@example.com"), Age: 20},
+		{ID: 2, Name: "test2"},
+	}
+
+	generated := make([]any, 0, len(resources))
+	reflected := make([]any, 0, len(resources))
+	for _, r := range resources {
+		generated = append(generated, r)
+		reflected = append(reflected, reflectedTemp(r))
+	}
+
+	sql, args, err := NewBatch(generated, WithTable("temp")).GenerateSQL()
+	s.Require().NoError(err)
+
+	expectedSQL, expectedArgs, err := NewBatch(reflected, WithTable("temp")).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal(expectedSQL, sql)
+	s.Equal(expectedArgs, args)
+	s.Equal("INSERT INTO temp (name, email, age) VALUES (?, ?, ?), (?, ?, ?)", sql)
+	s.Equal([]any{"test", "test@example.com", 20, "test2", nil, 0}, args)
+}
+
+func (s *insertableSuite) TestGenerateSQL_IncludePrimaryKey() {
+	// The generated builder excludes the primary key, so the batch falls back to reflection
+	sql, args, err := NewBatch([]any{
+		insertableTemp{ID: 1, Name: "test"},
+	}, WithTable("temp"), WithIncludePrimaryKey(true)).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("INSERT INTO temp (id, name, email, age) VALUES (?, ?, ?, ?)", sql)
+	s.Equal([]any{1, "test", nil, 0}, args)
+}
+
+func BenchmarkGenerateSQL(b *testing.B) {
+	type temp struct {
+		ID        int       `db:"id,pk"`
+		Name      string    `db:"name"`
+		Email     *string   `db:"email"`
+		Age       int       `db:"age"`
+		Active    bool      `db:"active"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+
+	resources := make([]any, 0, 100)
+	for i := range 100 {
+		resources = append(resources, &temp{ID: i, Name: "test", Age: i, Active: true})
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _, err := NewBatch(resources, WithTable("temp"), WithDialect(patcher.DialectPostgreSQL)).GenerateSQL()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Package fieldmeta parses and caches the reflection metadata of the struct fields patched by the patcher package
+// and inserted by the inserter package.
+package fieldmeta
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The tag names and options parsed from the struct tags. The patcher package exports them under the same names.
+const (
+	TagOptsName      = "patcher"
+	TagOptSeparator  = ","
+	TagOptSkip       = "-"
+	TagOptOmitempty  = "omitempty"
+	TagOptVersion    = "version"
+	TagOptInline     = "inline"
+	TagOptJSON       = "json"
+	TagOptPrefix     = "prefix"
+	TagOptIncrement  = "increment"
+	TagOptAutoCreate = "autocreate"
+	TagOptAutoUpdate = "autoupdate"
+	TagOptValueSep   = "="
+	DBTagPrimaryKey  = "pk"
+)
+
+// patcherPkgPath is the import path of the patcher package, which defines the Expression type
+const patcherPkgPath = "github.com/jacobbrewer1/patcher"
+
+var (
+	// ValuerType is the type of the driver.Valuer interface, which is shared with the patcher package
+	ValuerType = reflect.TypeFor[driver.Valuer]()
+
+	timeType          = reflect.TypeFor[time.Time]()
+	optionalValueType = reflect.TypeFor[optionalValue]()
+)
+
+// optionalValue has the method set of patcher.OptionalValue, which is implemented by every patcher.Optional
+type optionalValue interface {
+	IsSet() bool
+	IsNull() bool
+	AnyValue() any
+}
+
+// TagOptions is the parsed set of options of a struct tag
+type TagOptions uint16
+
+const (
+	OptSkip TagOptions = 1 << iota
+	OptOmitempty
+	OptVersion
+	OptInline
+	OptJSON
+	OptIncrement
+	OptAutoCreate
+	OptAutoUpdate
+	OptPrimaryKey
+)
+
+// tagOptionBit returns the bit of the tag option in TagOptions, or zero for unknown options
+func tagOptionBit(opt string) TagOptions {
+	switch opt {
+	case TagOptSkip:
+		return OptSkip
+	case TagOptOmitempty:
+		return OptOmitempty
+	case TagOptVersion:
+		return OptVersion
+	case TagOptInline:
+		return OptInline
+	case TagOptJSON:
+		return OptJSON
+	case TagOptIncrement:
+		return OptIncrement
+	case TagOptAutoCreate:
+		return OptAutoCreate
+	case TagOptAutoUpdate:
+		return OptAutoUpdate
+	case DBTagPrimaryKey:
+		return OptPrimaryKey
+	default:
+		return 0
+	}
+}
+
+// Has reports whether any of the options is set
+func (o TagOptions) Has(opts TagOptions) bool {
+	return o&opts != 0
+}
+
+// parseTagOptions parses the tag options separated by TagOptSeparator
+func parseTagOptions(opts string) TagOptions {
+	var parsed TagOptions
+	for opt := range strings.SplitSeq(opts, TagOptSeparator) {
+		parsed |= tagOptionBit(opt)
+	}
+
+	return parsed
+}
+
+// FieldMeta is the reflection metadata of a struct field, parsed once per struct type and tag name.
+type FieldMeta struct {
+	// Field is the struct field
+	Field reflect.StructField
+
+	// Tag is the column name set in the tag, or the empty string if no column name is set
+	Tag string
+
+	// Column is the column name of the field, defaulting to the lower-cased field name
+	Column string
+
+	// Options are the options of the patcher tag
+	Options TagOptions
+
+	// ColumnOptions are the options following the column name in the tag, such as DBTagPrimaryKey
+	ColumnOptions TagOptions
+
+	// Prefix is the column prefix of a flattened struct, set with the prefix tag option
+	Prefix string
+
+	// Flatten is true if the field is a struct flattened into its columns
+	Flatten bool
+
+	// Nested is true if the field is a struct that is not stored as a single value, such as time.Time
+	Nested bool
+
+	// Optional is true if the field is a patcher.Optional
+	Optional bool
+
+	// Expression is true if the field is a patcher.Expression
+	Expression bool
+
+	// ValidType is true if the kind of the field can be stored as a database field
+	ValidType bool
+
+	// Valuer is true if the type of the field, or a pointer to it, implements driver.Valuer
+	Valuer bool
+
+	// SetClause is the SET clause of the unprefixed and unquoted column
+	SetClause string
+}
+
+// fieldMetaKey is the key of the field metadata cache
+type fieldMetaKey struct {
+	typeOf  reflect.Type
+	tagName string
+}
+
+// fieldMetaCache caches the field metadata per struct type and tag name
+var fieldMetaCache sync.Map
+
+// TypeFields returns the metadata of the fields of the struct type, in field order. The metadata is parsed once per
+// struct type and tag name and the returned slice is shared, so it must not be modified.
+func TypeFields(typeOf reflect.Type, tagName string) []FieldMeta {
+	key := fieldMetaKey{typeOf: typeOf, tagName: tagName}
+	if cached, ok := fieldMetaCache.Load(key); ok {
+		return cached.([]FieldMeta)
+	}
+
+	fields := make([]FieldMeta, typeOf.NumField())
+	for i := range fields {
+		fields[i] = newFieldMeta(typeOf.Field(i), tagName)
+	}
+
+	cached, _ := fieldMetaCache.LoadOrStore(key, fields)
+	return cached.([]FieldMeta)
+}
+
+// newFieldMeta parses the metadata of the struct field
+func newFieldMeta(field reflect.StructField, tagName string) FieldMeta {
+	tag, columnOpts, _ := strings.Cut(field.Tag.Get(tagName), TagOptSeparator)
+	prefix, _ := tagOptValue(&field, TagOptPrefix)
+	_, flatten := FlattenPrefix(&field)
+
+	meta := FieldMeta{
+		Field:         field,
+		Tag:           tag,
+		Column:        ColumnName(&field, tagName),
+		Options:       parseTagOptions(field.Tag.Get(TagOptsName)),
+		ColumnOptions: parseTagOptions(columnOpts),
+		Prefix:        prefix,
+		Flatten:       flatten,
+		Nested:        field.Type.Kind() == reflect.Struct && !IsScalarStruct(field.Type),
+		Optional:      field.Type.Implements(optionalValueType),
+		Expression:    isExpression(field.Type),
+		ValidType:     ValidKind(field.Type.Kind()),
+		Valuer:        field.Type.Implements(ValuerType) || reflect.PointerTo(field.Type).Implements(ValuerType),
+	}
+	meta.SetClause = meta.Column + " = ?"
+
+	return meta
+}
+
+// ColumnName returns the column name set in the tag of the field, defaulting to the lower-cased field name.
+func ColumnName(field *reflect.StructField, tagName string) string {
+	tag := field.Tag.Get(tagName)
+	if tag == "" {
+		tag = strings.ToLower(field.Name)
+	}
+
+	tags := strings.Split(tag, TagOptSeparator)
+	if len(tags) > 1 {
+		return tags[0]
+	}
+	return tag
+}
+
+// hasTagOpt checks if the patcher options tag on the field contains the given option.
+func hasTagOpt(field *reflect.StructField, opt string) bool {
+	val, ok := field.Tag.Lookup(TagOptsName)
+	if !ok {
+		return false
+	}
+
+	return slices.Contains(strings.Split(val, TagOptSeparator), opt)
+}
+
+// tagOptValue returns the value of a key=value option in the patcher options tag on the field.
+func tagOptValue(field *reflect.StructField, key string) (string, bool) {
+	val, ok := field.Tag.Lookup(TagOptsName)
+	if !ok {
+		return "", false
+	}
+
+	for _, opt := range strings.Split(val, TagOptSeparator) {
+		if k, v, found := strings.Cut(opt, TagOptValueSep); found && k == key {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// isExpression checks if the type is patcher.Expression. The type is matched by its name, as the patcher package
+// cannot be imported here.
+func isExpression(typeOf reflect.Type) bool {
+	return typeOf.PkgPath() == patcherPkgPath && typeOf.Name() == "Expression"
+}
+
+// IsScalarStruct checks if the struct type should be stored as a single database value rather than being flattened
+// into its fields. This is the case for time.Time, patcher.Expression and any type implementing driver.Valuer.
+func IsScalarStruct(typeOf reflect.Type) bool {
+	return typeOf == timeType ||
+		isExpression(typeOf) ||
+		typeOf.Implements(ValuerType) ||
+		reflect.PointerTo(typeOf).Implements(ValuerType)
+}
+
+// FlattenPrefix determines whether the struct field should be flattened into its columns and returns the prefix to
+// apply to the nested column names.
+//
+// Exported embedded structs (and pointers to structs) are always flattened. Named nested structs are flattened when
+// tagged with the inline or prefix options. Structs that are scalars, such as time.Time, or that are encoded as JSON
+// are never flattened.
+func FlattenPrefix(field *reflect.StructField) (string, bool) {
+	typeOf := field.Type
+	if typeOf.Kind() == reflect.Ptr {
+		typeOf = typeOf.Elem()
+	}
+
+	if !field.IsExported() || typeOf.Kind() != reflect.Struct || IsScalarStruct(typeOf) || hasTagOpt(field, TagOptJSON) {
+		return "", false
+	}
+
+	prefix, hasPrefix := tagOptValue(field, TagOptPrefix)
+	if field.Anonymous || hasPrefix || hasTagOpt(field, TagOptInline) {
+		return prefix, true
+	}
+
+	return "", false
+}
+
+// ValidKind checks if values of the given kind can be stored as a database field.
+func ValidKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Struct, reflect.Ptr:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,107 @@
+package fieldmeta
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testOptional has the method set of patcher.Optional
+type testOptional struct{}
+
+func (testOptional) IsSet() bool   { return false }
+func (testOptional) IsNull() bool  { return false }
+func (testOptional) AnyValue() any { return nil }
+
+func TestParseTagOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     string
+		expected TagOptions
+	}{
+		{"Empty", "", 0},
+		{"Single option", "omitempty", OptOmitempty},
+		{"Multiple options", "json,omitempty,increment", OptJSON | OptOmitempty | OptIncrement},
+		{"Key value option", "prefix=address_,inline", OptInline},
+		{"Primary key", "pk,unique", OptPrimaryKey},
+		{"Unknown option", "unknown", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, parseTagOptions(tt.opts))
+		})
+	}
+}
+
+func TestTagOptions_Has(t *testing.T) {
+	t.Parallel()
+
+	opts := parseTagOptions("json,autoupdate")
+
+	require.True(t, opts.Has(OptJSON))
+	require.True(t, opts.Has(OptAutoUpdate))
+	require.True(t, opts.Has(OptAutoCreate|OptAutoUpdate))
+	require.False(t, opts.Has(OptAutoCreate))
+}
+
+func TestTypeFields(t *testing.T) {
+	t.Parallel()
+
+	type nested struct {
+		City string `db:"city"`
+	}
+
+	type testObj struct {
+		ID        int       `db:"id,pk"`
+		Name      string    `db:"name" patcher:"omitempty"`
+		Email     string    `db:""`
+		CreatedAt time.Time `db:"created_at" patcher:"autocreate"`
+		Address   nested    `patcher:"prefix=address_"`
+		Count     testOptional
+		Tags      []string
+		hidden    string
+	}
+
+	fields := TypeFields(reflect.TypeFor[testObj](), "db")
+	require.Len(t, fields, 8)
+
+	require.Equal(t, "id", fields[0].Tag)
+	require.Equal(t, "id", fields[0].Column)
+	require.True(t, fields[0].ColumnOptions.Has(OptPrimaryKey))
+	require.Equal(t, "id = ?", fields[0].SetClause)
+
+	require.Equal(t, "name", fields[1].Column)
+	require.True(t, fields[1].Options.Has(OptOmitempty))
+
+	require.Empty(t, fields[2].Tag)
+	require.Equal(t, "email", fields[2].Column)
+
+	require.True(t, fields[3].Options.Has(OptAutoCreate))
+	require.False(t, fields[3].Nested)
+	require.True(t, fields[3].ValidType)
+
+	require.True(t, fields[4].Flatten)
+	require.True(t, fields[4].Nested)
+	require.Equal(t, "address_", fields[4].Prefix)
+
+	require.True(t, fields[5].Optional)
+	require.False(t, fields[5].Expression)
+	require.False(t, fields[6].ValidType)
+	require.False(t, fields[7].Field.IsExported())
+
+	// The metadata is parsed once and shared
+	cached := TypeFields(reflect.TypeFor[testObj](), "db")
+	require.Same(t, &fields[0], &cached[0])
+
+	// The metadata is cached per tag name
+	other := TypeFields(reflect.TypeFor[testObj](), "other")
+	require.NotSame(t, &fields[0], &other[0])
+	require.Equal(t, "id", other[0].Column)
+	require.Empty(t, other[0].Tag)
+}
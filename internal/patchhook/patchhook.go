@@ -0,0 +1,13 @@
+// Package patchhook gives the other packages of the module access to behaviour of the patcher package that is not
+// part of its public API. The hooks are set when the patcher package is initialised.
+package patchhook
+
+import "errors"
+
+// ErrPrimaryKeyWrite is returned when a merge patch document sets a primary key of a patch configured with
+// RejectPrimaryKeys
+var ErrPrimaryKeyWrite = errors.New("primary key cannot be written")
+
+// RejectPrimaryKeys configures the *patcher.SQLPatch to reject merge patch documents setting a primary key, rather
+// than using the key to generate the where clause.
+var RejectPrimaryKeys func(patch any)
@@ -1,23 +1,228 @@
 package patcher
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrJoinAliasCollision is returned when two different joins use the same alias
+var ErrJoinAliasCollision = errors.New("join alias collision")
 
 // Joiner is an interface that can be used to specify the JOIN clause to use when the SQL is being generated.
+// The clause may use ? or $1, $2, $3 placeholders, which are numbered again when the SQL is generated.
 type Joiner interface {
 	Join() (string, []any)
 }
 
-func appendJoin(join Joiner, builder *strings.Builder, args *[]any) {
+// JoinType is the type of JOIN clause
+type JoinType string
+
+const (
+	JoinTypeInner JoinType = "INNER JOIN"
+	JoinTypeLeft  JoinType = "LEFT JOIN"
+)
+
+// JoinClause is a structured JOIN clause implementing Joiner. The table and alias are used as given, and the ON
+// conditions are combined with "AND":
+//
+//	patcher.InnerJoin("organisations").As("o").On(
+//		patcher.OnColumns("o.id", "users.org_id"),
+//		where.Eq("o.region", "eu"),
+//	)
+//
+// Identical joins are only added once to a MultiFilter or SQLPatch, while different joins using the same alias, or
+// the same table without an alias, are rejected with ErrJoinAliasCollision.
+type JoinClause struct {
+	joinType JoinType
+	table    string
+	alias    string
+	on       []Wherer
+}
+
+// InnerJoin creates an INNER JOIN on the table with the given ON conditions
+func InnerJoin(table string, on ...Wherer) *JoinClause {
+	return &JoinClause{
+		joinType: JoinTypeInner,
+		table:    table,
+		on:       on,
+	}
+}
+
+// LeftJoin creates a LEFT JOIN on the table with the given ON conditions
+func LeftJoin(table string, on ...Wherer) *JoinClause {
+	return &JoinClause{
+		joinType: JoinTypeLeft,
+		table:    table,
+		on:       on,
+	}
+}
+
+// As returns a copy of the join using the alias for the table
+func (j *JoinClause) As(alias string) *JoinClause {
+	join := *j
+	join.alias = alias
+	return &join
+}
+
+// On returns a copy of the join with the conditions added to its ON clause
+func (j *JoinClause) On(conditions ...Wherer) *JoinClause {
+	join := *j
+	join.on = append(append(make([]Wherer, 0, len(j.on)+len(conditions)), j.on...), conditions...)
+	return &join
+}
+
+// Join returns the JOIN clause and the arguments of its ON conditions
+func (j *JoinClause) Join() (sqlStr string, args []any) {
+	// The string literals are read as in the default dialect. Placeholders that do not match the arguments are
+	// reported when the SQL of the patch is generated.
+	sqlStr, args, _ = j.join(defaultSyntax)
+	return sqlStr, args
+}
+
+// join renders the join, or returns an error if the placeholders of an ON condition do not match its arguments.
+// The quoted parts of the conditions are read with the given syntax.
+func (j *JoinClause) join(syntax sqlSyntax) (sqlStr string, args []any, err error) {
+	builder := new(strings.Builder)
+	builder.WriteString(string(j.joinType))
+	builder.WriteString(" ")
+	builder.WriteString(j.table)
+
+	if j.alias != "" {
+		builder.WriteString(" AS ")
+		builder.WriteString(j.alias)
+	}
+
+	conditions, args, err := groupConditions(j.on, false, syntax)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(conditions) > 0 {
+		builder.WriteString(" ON ")
+		builder.WriteString(strings.Join(conditions, " AND "))
+	}
+
+	return builder.String(), args, nil
+}
+
+// joinAlias returns the name the joined table is referenced by
+func (j *JoinClause) joinAlias() string {
+	if j.alias != "" {
+		return j.alias
+	}
+	return j.table
+}
+
+// OnColumns creates an ON condition comparing two columns, such as "o.id = users.org_id"
+func OnColumns(left, right string) Wherer {
+	return &whereStringOption{
+		where: left + " = " + right,
+	}
+}
+
+// joinLister is implemented by filters combining several joins, so that each join is deduplicated on its own when
+// the filter is added to a JOIN clause.
+type joinLister interface {
+	joins() ([]Joiner, error)
+}
+
+// joinEntry is a join added to a JOIN clause
+type joinEntry struct {
+	sql   string
+	args  []any
+	alias string
+}
+
+// joinSet tracks the joins added to a JOIN clause. The zero value is ready to use.
+type joinSet struct {
+	entries []joinEntry
+}
+
+// add records the join, returning false when an identical join has already been added. An error is returned when
+// a different join has already been added with the same alias.
+func (j *joinSet) add(sqlStr string, args []any, alias string) (bool, error) {
+	entry := joinEntry{
+		sql:   strings.Join(strings.Fields(sqlStr), " "),
+		args:  args,
+		alias: alias,
+	}
+
+	for _, existing := range j.entries {
+		if existing.sql == entry.sql && reflect.DeepEqual(existing.args, entry.args) {
+			return false, nil
+		}
+
+		if alias != "" && strings.EqualFold(existing.alias, alias) {
+			return false, fmt.Errorf("%w: %s is used by %q and %q", ErrJoinAliasCollision, alias, existing.sql, entry.sql)
+		}
+	}
+
+	j.entries = append(j.entries, entry)
+	return true, nil
+}
+
+// appendJoin adds the join to the JOIN clause unless an identical join has already been added. The $1, $2, $3
+// placeholders of the join are converted to ? placeholders, and an error is returned if the placeholders do not match
+// the arguments. The quoted parts of the join are read with the given syntax.
+func appendJoin(join Joiner, builder *strings.Builder, args *[]any, joins *joinSet, syntax sqlSyntax) error {
 	if join == nil {
-		return
+		return nil
+	}
+
+	if lister, ok := join.(joinLister); ok {
+		listed, err := lister.joins()
+		if err != nil {
+			return err
+		}
+
+		for _, j := range listed {
+			if err := appendJoin(j, builder, args, joins, syntax); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		jSQL  string
+		jArgs []any
+		alias string
+		err   error
+	)
+	if clause, ok := join.(*JoinClause); ok {
+		jSQL, jArgs, err = clause.join(syntax)
+		alias = clause.joinAlias()
+	} else {
+		jSQL, jArgs = join.Join()
+		jSQL, jArgs, err = normalizePlaceholders(strings.TrimSpace(jSQL), jArgs, syntax)
+	}
+	if err != nil {
+		return err
+	}
+	if jSQL == "" {
+		return nil
 	}
-	jSQL, jArgs := join.Join()
 	if jArgs == nil {
 		jArgs = make([]any, 0)
 	}
-	builder.WriteString(strings.TrimSpace(jSQL))
+
+	added, err := joins.add(jSQL, jArgs, alias)
+	if err != nil || !added {
+		return err
+	}
+
+	builder.WriteString(jSQL)
 	builder.WriteString("\n")
 	*args = append(*args, jArgs...)
+	return nil
+}
+
+// appendJoin adds the join to the join clause of the patch, recording the first error encountered
+func (s *SQLPatch) appendJoin(join Joiner) {
+	if err := appendJoin(join, s.joinSql, &s.joinArgs, &s.joins, dialectSyntax(s.dialect)); err != nil && s.genErr == nil {
+		s.genErr = err
+	}
 }
 
 type joinStringOption struct {
@@ -0,0 +1,203 @@
+package patcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type joinerSuite struct {
+	suite.Suite
+}
+
+func TestJoinerSuite(t *testing.T) {
+	suite.Run(t, new(joinerSuite))
+}
+
+func (s *joinerSuite) TestJoinClause_Join() {
+	region := &whereStringOption{where: "o.region = ?", args: []any{"eu"}}
+
+	tests := []struct {
+		name     string
+		join     *JoinClause
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "Inner join",
+			join:     InnerJoin("orgs", OnColumns("orgs.id", "users.org_id")),
+			wantSQL:  "INNER JOIN orgs ON orgs.id = users.org_id",
+			wantArgs: []any{},
+		},
+		{
+			name:     "Left join with alias",
+			join:     LeftJoin("orgs").As("o").On(OnColumns("o.id", "users.org_id"), region),
+			wantSQL:  "LEFT JOIN orgs AS o ON o.id = users.org_id AND o.region = ?",
+			wantArgs: []any{"eu"},
+		},
+		{
+			name:     "Compound condition wrapped",
+			join:     InnerJoin("orgs", &whereStringOption{where: "orgs.a = ? OR orgs.b = ?", args: []any{1, 2}}, region),
+			wantSQL:  "INNER JOIN orgs ON (orgs.a = ? OR orgs.b = ?) AND o.region = ?",
+			wantArgs: []any{1, 2, "eu"},
+		},
+		{
+			name:     "Group condition",
+			join:     InnerJoin("orgs", OrGroup(region, OnColumns("o.id", "users.org_id"))),
+			wantSQL:  "INNER JOIN orgs ON (o.region = ? OR o.id = users.org_id)",
+			wantArgs: []any{"eu"},
+		},
+		{
+			name:     "No conditions",
+			join:     InnerJoin("orgs"),
+			wantSQL:  "INNER JOIN orgs",
+			wantArgs: []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			sql, args := tt.join.Join()
+			s.Equal(tt.wantSQL, sql)
+			s.Equal(tt.wantArgs, args)
+		})
+	}
+}
+
+func (s *joinerSuite) TestJoinClause_Copies() {
+	join := InnerJoin("orgs")
+	aliased := join.As("o")
+	withOn := aliased.On(OnColumns("o.id", "users.org_id"))
+
+	sql, _ := join.Join()
+	s.Equal("INNER JOIN orgs", sql)
+
+	sql, _ = aliased.Join()
+	s.Equal("INNER JOIN orgs AS o", sql)
+
+	sql, _ = withOn.Join()
+	s.Equal("INNER JOIN orgs AS o ON o.id = users.org_id", sql)
+}
+
+func (s *joinerSuite) TestMultiFilter_Dedupe() {
+	mf := NewMultiFilter()
+	mf.Add(InnerJoin("orgs", OnColumns("orgs.id", "users.org_id")))
+	mf.Add(InnerJoin("orgs", OnColumns("orgs.id", "users.org_id")))
+	mf.Add(&joinStringOption{join: "JOIN teams ON teams.id = users.team_id AND teams.active = ?", args: []any{true}})
+	mf.Add(&joinStringOption{join: "  JOIN teams  ON teams.id = users.team_id AND teams.active = ?", args: []any{true}})
+	mf.Add(&joinStringOption{join: "JOIN teams ON teams.id = users.team_id AND teams.active = ?", args: []any{false}})
+
+	sql, args := mf.Join()
+	s.Equal("INNER JOIN orgs ON orgs.id = users.org_id\n"+
+		"JOIN teams ON teams.id = users.team_id AND teams.active = ?\n"+
+		"JOIN teams ON teams.id = users.team_id AND teams.active = ?\n", sql)
+	s.Equal([]any{true, false}, args)
+}
+
+func (s *joinerSuite) TestSQLPatch_Dedupe() {
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	join := InnerJoin("orgs").As("o").On(OnColumns("o.id", "users.org_id"), &whereStringOption{
+		where: "o.region = ?",
+		args:  []any{"eu"},
+	})
+
+	mf := NewMultiFilter()
+	mf.Add(join)
+	mf.Add(&whereStringOption{where: "o.name = ?", args: []any{"acme"}})
+
+	sqlStr, args, err := NewSQLPatch(user{Name: "John"},
+		WithTable("users"),
+		WithJoin(join),
+		WithFilter(mf),
+		WithFilter(AndGroup(&joinFilter{join, &whereStringOption{where: "o.active = ?", args: []any{true}}})),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\n"+
+		"INNER JOIN orgs AS o ON o.id = users.org_id AND o.region = ?\n"+
+		"SET name = ?\n"+
+		"WHERE (1=1)\n"+
+		"AND (\n"+
+		"(o.name = ?)\n"+
+		"AND (o.active = ?)\n"+
+		")", sqlStr)
+	s.Equal([]any{"eu", "John", "acme", true}, args)
+}
+
+func (s *joinerSuite) TestAliasCollision() {
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	orgs := InnerJoin("orgs").As("o").On(OnColumns("o.id", "users.org_id"))
+	owners := LeftJoin("owners").As("O").On(OnColumns("O.id", "users.owner_id"))
+
+	tests := []struct {
+		name string
+		opts []PatchOpt
+	}{
+		{
+			name: "Patch joins",
+			opts: []PatchOpt{WithJoin(orgs), WithJoin(owners)},
+		},
+		{
+			name: "Same table without alias",
+			opts: []PatchOpt{
+				WithJoin(InnerJoin("orgs", OnColumns("orgs.id", "users.org_id"))),
+				WithJoin(InnerJoin("orgs", OnColumns("orgs.id", "users.parent_org_id"))),
+			},
+		},
+		{
+			name: "MultiFilter joins",
+			opts: []PatchOpt{WithFilter(func() MultiFilter {
+				mf := NewMultiFilter()
+				mf.Add(orgs)
+				mf.Add(owners)
+				return mf
+			}())},
+		},
+		{
+			name: "MultiFilter and patch joins",
+			opts: []PatchOpt{WithJoin(orgs), WithFilter(func() MultiFilter {
+				mf := NewMultiFilter()
+				mf.Add(owners)
+				return mf
+			}())},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			opts := append([]PatchOpt{WithTable("users")}, tt.opts...)
+			_, _, err := NewSQLPatch(user{ID: 1, Name: "John"}, opts...).GenerateSQL()
+			s.Require().ErrorIs(err, ErrJoinAliasCollision)
+		})
+	}
+}
+
+func (s *joinerSuite) TestAliasCollision_Message() {
+	mf := NewMultiFilter()
+	mf.Add(InnerJoin("orgs").As("o"))
+	mf.Add(InnerJoin("owners").As("o"))
+
+	sql, _ := mf.Join()
+	s.Equal("INNER JOIN orgs AS o\n", sql)
+
+	_, _, err := NewSQLPatch(struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}{ID: 1, Name: "John"}, WithTable("users"), WithFilter(mf)).GenerateSQL()
+	s.Require().EqualError(err, `generate patch: join alias collision: o is used by "INNER JOIN orgs AS o" and `+
+		`"INNER JOIN owners AS o"`)
+}
+
+// joinFilter combines a join with a where clause
+type joinFilter struct {
+	Joiner
+	Wherer
+}
@@ -0,0 +1,231 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jacobbrewer1/patcher"
+	"github.com/jacobbrewer1/patcher/internal/patchhook"
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpTest    = "test"
+	OpMove    = "move"
+	OpCopy    = "copy"
+)
+
+var (
+	// ErrInvalidDocument is returned when the JSON Patch document cannot be decoded
+	ErrInvalidDocument = errors.New("invalid json patch document")
+
+	// ErrUnsupportedOperation is returned when an operation cannot be translated into an SQL update
+	ErrUnsupportedOperation = errors.New("unsupported json patch operation")
+
+	// ErrInvalidPath is returned when the path of an operation is not a valid JSON Pointer or does not address a
+	// member of an object
+	ErrInvalidPath = errors.New("invalid json patch path")
+
+	// ErrMissingValue is returned when an operation that requires a value does not provide one
+	ErrMissingValue = errors.New("missing json patch value")
+)
+
+// Operation is a single operation of a JSON Patch (RFC 6902) document.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+	From  string          `json:"from,omitempty"`
+}
+
+// Decode decodes a JSON Patch document into its operations.
+func Decode(body []byte) ([]Operation, error) {
+	ops := make([]Operation, 0)
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDocument, err)
+	}
+
+	return ops, nil
+}
+
+// NewSQLPatch creates a new patcher.SQLPatch for the resource type T from a JSON Patch (RFC 6902) document.
+//
+// The "replace" and "add" operations become SET clauses and "remove" sets the column to NULL. The "test" operations
+// become extra WHERE conditions so that the update only applies when the precondition still holds in the database.
+// Primary keys can only be tested, writing them results in ErrInvalidPath.
+// Paths are resolved against the json tags of T in the same way as patcher.NewSQLPatchFromMergePatch, and only
+// members of objects can be addressed; array indices and the "move" and "copy" operations are not supported.
+func NewSQLPatch[T any](body []byte, opts ...patcher.PatchOpt) (*patcher.SQLPatch, error) {
+	ops, err := Decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSQLPatchFromOperations[T](ops, opts...)
+}
+
+// NewSQLPatchFromOperations creates a new patcher.SQLPatch for the resource type T from decoded JSON Patch operations.
+// See NewSQLPatch for details.
+func NewSQLPatchFromOperations[T any](ops []Operation, opts ...patcher.PatchOpt) (*patcher.SQLPatch, error) {
+	doc := make(map[string]any)
+	testOpts := make([]patcher.PatchOpt, 0)
+
+	for i, op := range ops {
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+
+		switch op.Op {
+		case OpAdd, OpReplace:
+			if op.Value == nil {
+				return nil, fmt.Errorf("operation %d: %w", i, ErrMissingValue)
+			}
+
+			if err := setMember(doc, tokens, op.Value); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		case OpRemove:
+			if err := setMember(doc, tokens, json.RawMessage("null")); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		case OpTest:
+			if op.Value == nil {
+				return nil, fmt.Errorf("operation %d: %w", i, ErrMissingValue)
+			}
+
+			testDoc, err := testDocument(tokens, op.Value)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+
+			testOpts = append(testOpts, patcher.WithMergeConditions(testDoc))
+		default:
+			return nil, fmt.Errorf("operation %d: %w: %q", i, ErrUnsupportedOperation, op.Op)
+		}
+	}
+
+	mergeDoc, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("encode merge patch: %w", err)
+	}
+
+	// Writing a primary key changes the key of the row, so it cannot be used to select the row as in a merge patch
+	rejectPrimaryKeys := func(s *patcher.SQLPatch) {
+		patchhook.RejectPrimaryKeys(s)
+	}
+
+	patchOpts := slices.Concat(opts, testOpts, []patcher.PatchOpt{rejectPrimaryKeys})
+	patch, err := patcher.NewSQLPatchFromMergePatch[T](mergeDoc, patchOpts...)
+	if errors.Is(err, patchhook.ErrPrimaryKeyWrite) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPath, err)
+	}
+
+	return patch, err
+}
+
+// testDocument translates the value of a test operation into a merge patch document, which is resolved onto the
+// columns tested by the operation with patcher.WithMergeConditions.
+func testDocument(tokens []string, value json.RawMessage) ([]byte, error) {
+	doc := make(map[string]any)
+	if err := setMember(doc, tokens, value); err != nil {
+		return nil, err
+	}
+
+	testDoc, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("encode test value: %w", err)
+	}
+
+	return testDoc, nil
+}
+
+// setMember sets the value at the path of object member names in the merge document, creating nested objects as
+// required. A nested path below a member that has already been set to an object is merged into that object.
+func setMember(doc map[string]any, tokens []string, value json.RawMessage) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("%w: the whole document cannot be patched", ErrInvalidPath)
+	}
+
+	node := doc
+	for _, token := range tokens[:len(tokens)-1] {
+		next, err := childObject(node, token)
+		if err != nil {
+			return err
+		}
+		node = next
+	}
+
+	node[tokens[len(tokens)-1]] = value
+	return nil
+}
+
+// childObject returns the nested object for the member of the node, decoding a previously set JSON object value so
+// that it can be merged into.
+func childObject(node map[string]any, token string) (map[string]any, error) {
+	switch existing := node[token].(type) {
+	case nil:
+		child := make(map[string]any)
+		node[token] = child
+		return child, nil
+	case map[string]any:
+		return existing, nil
+	case json.RawMessage:
+		members := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(existing, &members); err != nil || members == nil {
+			return nil, fmt.Errorf("%w: %q is not an object", ErrInvalidPath, token)
+		}
+
+		child := make(map[string]any, len(members))
+		for k, v := range members {
+			child[k] = v
+		}
+		node[token] = child
+		return child, nil
+	default:
+		return nil, fmt.Errorf("%w: %q is not an object", ErrInvalidPath, token)
+	}
+}
+
+// parsePointer parses a JSON Pointer (RFC 6901) into its unescaped reference tokens. Array indices are rejected as
+// they cannot be mapped onto a column.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: %q must start with '/'", ErrInvalidPath, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		if token == "-" || isArrayIndex(token) {
+			return nil, fmt.Errorf("%w: array elements cannot be addressed in %q", ErrInvalidPath, pointer)
+		}
+
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// isArrayIndex checks if the reference token is an array index
+func isArrayIndex(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,210 @@
+package jsonpatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jacobbrewer1/patcher"
+	"github.com/stretchr/testify/suite"
+)
+
+type address struct {
+	City   *string `db:"city" json:"city"`
+	Street *string `db:"street" json:"street"`
+}
+
+type user struct {
+	Name    *string `db:"name" json:"name"`
+	Email   *string `db:"email" json:"email"`
+	Age     int     `db:"age" json:"age"`
+	Address address `json:"address" patcher:"prefix=addr_"`
+	Slash   *string `db:"slash" json:"a/b"`
+}
+
+type versionedUser struct {
+	Name      *string   `db:"name" json:"name"`
+	Version   int       `db:"version" json:"version" patcher:"version"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at" patcher:"autoupdate"`
+}
+
+type keyedUser struct {
+	ID   int     `db:"id,pk" json:"id"`
+	Name *string `db:"name" json:"name"`
+}
+
+type newSQLPatchSuite struct {
+	suite.Suite
+}
+
+func TestNewSQLPatchSuite(t *testing.T) {
+	suite.Run(t, new(newSQLPatchSuite))
+}
+
+func (s *newSQLPatchSuite) TestNewSQLPatch() {
+	body := []byte(`[
+		{"op": "replace", "path": "/name", "value": "john"},
+		{"op": "add", "path": "/age", "value": 0},
+		{"op": "remove", "path": "/email"},
+		{"op": "replace", "path": "/address/city", "value": "London"},
+		{"op": "replace", "path": "/a~1b", "value": "slash"}
+	]`)
+
+	patch, err := NewSQLPatch[user](body,
+		patcher.WithTable("users"),
+		patcher.WithWhereStr("id = ?", 1),
+	)
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, email = ?, age = ?, addr_city = ?, slash = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"john", nil, 0, "London", "slash", 1}, args)
+}
+
+func (s *newSQLPatchSuite) TestNewSQLPatch_Test() {
+	body := []byte(`[
+		{"op": "test", "path": "/name", "value": "john"},
+		{"op": "test", "path": "/email", "value": null},
+		{"op": "replace", "path": "/name", "value": "jane"}
+	]`)
+
+	patch, err := NewSQLPatch[user](body,
+		patcher.WithTable("users"),
+		patcher.WithWhereStr("id = ?", 1),
+		patcher.WithDialect(patcher.DialectPostgreSQL),
+	)
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = $1\nWHERE (1=1)\nAND (\nid = $2\n)\nAND name = $3\nAND email IS NULL", sqlStr)
+	s.Equal([]any{"jane", 1, "john"}, args)
+}
+
+func (s *newSQLPatchSuite) TestNewSQLPatch_TestWithSet() {
+	body := []byte(`[
+		{"op": "test", "path": "/age", "value": 3},
+		{"op": "test", "path": "/address/city", "value": "London"},
+		{"op": "replace", "path": "/name", "value": "jane"}
+	]`)
+
+	patch, err := NewSQLPatch[user](body,
+		patcher.WithTable("users"),
+		patcher.WithWhereStr("id = ?", 1),
+		patcher.WithSet("age", patcher.Expr("age + ?", 1)),
+	)
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, age = age + ?\nWHERE (1=1)\nAND (\nid = ?\n)\nAND age = ?\nAND addr_city = ?", sqlStr)
+	s.Equal([]any{"jane", 1, 1, 3, "London"}, args)
+}
+
+func (s *newSQLPatchSuite) TestNewSQLPatch_TestTimestampsAndVersion() {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	body := []byte(`[
+		{"op": "test", "path": "/name", "value": "john"},
+		{"op": "test", "path": "/version", "value": 3},
+		{"op": "replace", "path": "/name", "value": "jane"}
+	]`)
+
+	patch, err := NewSQLPatch[versionedUser](body,
+		patcher.WithTable("users"),
+		patcher.WithWhereStr("id = ?", 1),
+		patcher.WithClock(func() time.Time { return now }),
+	)
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, updated_at = ?\nWHERE (1=1)\nAND (\nid = ?\n)\nAND name = ?\nAND version = ?", sqlStr)
+	s.Equal([]any{"jane", now, 1, "john", 3}, args)
+}
+
+func (s *newSQLPatchSuite) TestNewSQLPatch_WritePrimaryKey() {
+	for _, op := range []string{OpAdd, OpReplace, OpRemove} {
+		s.Run(op, func() {
+			body := []byte(`[
+				{"op": "test", "path": "/name", "value": "john"},
+				{"op": "` + op + `", "path": "/id", "value": 5},
+				{"op": "replace", "path": "/name", "value": "jane"}
+			]`)
+
+			// Writing the primary key must not select another row
+			patch, err := NewSQLPatch[keyedUser](body, patcher.WithTable("users"))
+			s.Require().ErrorIs(err, ErrInvalidPath)
+			s.Require().ErrorContains(err, "primary key cannot be written: id")
+			s.Nil(patch)
+		})
+	}
+}
+
+func (s *newSQLPatchSuite) TestNewSQLPatch_TestPrimaryKey() {
+	body := []byte(`[
+		{"op": "test", "path": "/id", "value": 5},
+		{"op": "replace", "path": "/name", "value": "jane"}
+	]`)
+
+	patch, err := NewSQLPatch[keyedUser](body,
+		patcher.WithTable("users"),
+		patcher.WithWhereStr("tenant_id = ?", 2),
+	)
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?\nWHERE (1=1)\nAND (\ntenant_id = ?\n)\nAND id = ?", sqlStr)
+	s.Equal([]any{"jane", 2, 5}, args)
+
+	// The test conditions are not a replacement for the where clause
+	patch, err = NewSQLPatch[keyedUser](body, patcher.WithTable("users"))
+	s.Require().NoError(err)
+
+	_, _, err = patch.GenerateSQL()
+	s.Require().ErrorIs(err, patcher.ErrNoWhere)
+}
+
+func (s *newSQLPatchSuite) TestNewSQLPatch_MergeNestedReplace() {
+	body := []byte(`[
+		{"op": "replace", "path": "/address", "value": {"city": "London"}},
+		{"op": "replace", "path": "/address/street", "value": "High Street"}
+	]`)
+
+	patch, err := NewSQLPatch[user](body)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"addr_city = ?", "addr_street = ?"}, patch.Fields())
+	s.Equal([]any{"London", "High Street"}, patch.Args())
+}
+
+func (s *newSQLPatchSuite) TestNewSQLPatch_Errors() {
+	tests := []struct {
+		name string
+		body string
+		err  error
+	}{
+		{"invalid document", `{"op": "replace"}`, ErrInvalidDocument},
+		{"unsupported operation", `[{"op": "move", "from": "/name", "path": "/email"}]`, ErrUnsupportedOperation},
+		{"array index", `[{"op": "add", "path": "/tags/0", "value": "a"}]`, ErrInvalidPath},
+		{"append", `[{"op": "add", "path": "/tags/-", "value": "a"}]`, ErrInvalidPath},
+		{"relative path", `[{"op": "add", "path": "name", "value": "a"}]`, ErrInvalidPath},
+		{"whole document", `[{"op": "replace", "path": "", "value": {}}]`, ErrInvalidPath},
+		{"missing value", `[{"op": "replace", "path": "/name"}]`, ErrMissingValue},
+		{"unknown field", `[{"op": "replace", "path": "/unknown", "value": 1}]`, patcher.ErrUnknownField},
+		{"not an object", `[{"op": "replace", "path": "/name", "value": "a"}, {"op": "replace", "path": "/name/first", "value": "a"}]`, ErrInvalidPath},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			patch, err := NewSQLPatch[user]([]byte(tt.body))
+			s.Require().ErrorIs(err, tt.err)
+			s.Nil(patch)
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package patcher
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrPlaceholderMismatch is returned when the placeholders of a WHERE or JOIN fragment do not match its arguments
+var ErrPlaceholderMismatch = errors.New("placeholders do not match the arguments")
+
+// sqlToken is a token found by the sqlLexer
+type sqlToken int
+
+const (
+	// tokenEnd is returned once the whole statement has been scanned
+	tokenEnd sqlToken = iota
+
+	// tokenPlaceholder is a ? parameter placeholder
+	tokenPlaceholder
+
+	// tokenEscapedMark is a ?? escape, written as a literal question mark
+	tokenEscapedMark
+
+	// tokenNumberedPlaceholder is a $1, $2, $3 parameter placeholder
+	tokenNumberedPlaceholder
+)
+
+// sqlSyntax describes how the quoted parts of a SQL statement are written in a dialect
+type sqlSyntax struct {
+	// backslashEscapes determines whether " delimits a string literal and a backslash escapes the next character of
+	// every string literal, as in MySQL. Otherwise " delimits a quoted identifier and only the escape strings of
+	// PostgreSQL, such as E'it\'s', use backslash escapes.
+	backslashEscapes bool
+
+	// bracketIdentifiers determines whether [ and ] delimit a quoted identifier, as in SQL Server
+	bracketIdentifiers bool
+}
+
+// defaultSyntax is the syntax of the SQL returned by the Where and Join methods, which are not tied to a dialect
+var defaultSyntax = dialectSyntax(DialectMySQL)
+
+// dialectSyntax returns the sqlSyntax of the dialect
+func dialectSyntax(dialect Dialect) sqlSyntax {
+	return sqlSyntax{
+		backslashEscapes:   dialect.BackslashEscapes(),
+		bracketIdentifiers: strings.HasPrefix(dialect.QuoteIdentifier("x"), "["),
+	}
+}
+
+// sqlLexer scans a SQL statement for its ? and $n parameter placeholders.
+//
+// Question marks inside quoted strings, quoted identifiers, comments and dollar-quoted bodies are not placeholders.
+// The PostgreSQL ?| and ?& operators are left untouched, and ?? is an escaped literal question mark, which allows the
+// PostgreSQL ? operator to be written in a statement using numbered placeholders.
+type sqlLexer struct {
+	sql string
+	pos int
+
+	// syntax determines how the quoted parts of the statement are read
+	syntax sqlSyntax
+
+	// tokenStart is the position of the last token returned
+	tokenStart int
+}
+
+// newSQLLexer returns a sqlLexer for the SQL statement written with the given syntax
+func newSQLLexer(sqlStr string, syntax sqlSyntax) *sqlLexer {
+	return &sqlLexer{
+		sql:    sqlStr,
+		syntax: syntax,
+	}
+}
+
+// next returns the text up to the next token and the token. At the end of the statement, the remaining text is
+// returned with tokenEnd.
+func (l *sqlLexer) next() (string, sqlToken) {
+	start := l.pos
+
+	for l.pos < len(l.sql) {
+		i := l.pos
+		switch c := l.sql[i]; {
+		case c == '?':
+			text := l.sql[start:i]
+			l.tokenStart = i
+			switch {
+			case l.peek(1) == '?':
+				l.pos += 2
+				return text, tokenEscapedMark
+			case (l.peek(1) == '|' || l.peek(1) == '&') && l.peek(2) != l.peek(1):
+				// The ?| and ?& operators, while ?|| and ?&& are a placeholder followed by an operator
+				l.pos += 2
+				continue
+			default:
+				l.pos++
+				return text, tokenPlaceholder
+			}
+		case c == '$' && (i == 0 || !isIdentByte(l.sql[i-1])) && isDigitByte(l.peek(1)):
+			l.tokenStart = i
+			l.pos++
+			for l.pos < len(l.sql) && isDigitByte(l.sql[l.pos]) {
+				l.pos++
+			}
+			return l.sql[start:i], tokenNumberedPlaceholder
+		case !l.skipQuotedPart():
+			l.pos++
+		}
+	}
+
+	return l.sql[start:], tokenEnd
+}
+
+// skipQuotedPart skips the string literal, quoted identifier, comment or dollar-quoted body starting at the current
+// position, returning false when none starts there. A $ that does not start a dollar quote is skipped on its own.
+func (l *sqlLexer) skipQuotedPart() bool {
+	i := l.pos
+	switch c := l.sql[i]; {
+	case c == '\'':
+		l.skipString(c, l.syntax.backslashEscapes || isEscapeString(l.sql, i))
+	case c == '"' && l.syntax.backslashEscapes:
+		l.skipString(c, true)
+	case c == '"' || c == '`':
+		l.skipQuoted(c)
+	case c == '[' && l.syntax.bracketIdentifiers:
+		l.skipQuoted(']')
+	case c == '-' && l.peek(1) == '-':
+		l.skipLineComment()
+	case c == '/' && l.peek(1) == '*':
+		l.skipBlockComment()
+	case c == '$' && (i == 0 || !isIdentByte(l.sql[i-1])):
+		l.skipDollarQuoted()
+	default:
+		return false
+	}
+	return true
+}
+
+// token returns the text of the last token returned
+func (l *sqlLexer) token() string {
+	return l.sql[l.tokenStart:l.pos]
+}
+
+// peek returns the byte at the offset from the current position, or 0 past the end of the statement
+func (l *sqlLexer) peek(offset int) byte {
+	if l.pos+offset >= len(l.sql) {
+		return 0
+	}
+	return l.sql[l.pos+offset]
+}
+
+// skipString skips the string literal delimited by the quote at the current position. Quotes are escaped by doubling
+// them, and by a backslash when backslashEscapes is set.
+func (l *sqlLexer) skipString(quote byte, backslashEscapes bool) {
+	l.pos++
+	for l.pos < len(l.sql) {
+		switch l.sql[l.pos] {
+		case '\\':
+			if backslashEscapes {
+				l.pos++
+			}
+		case quote:
+			if l.peek(1) != quote {
+				l.pos++
+				return
+			}
+			l.pos++
+		}
+		l.pos++
+	}
+}
+
+// skipQuoted skips the quoted identifier at the current position, up to the closing quote. Closing quotes are escaped
+// by doubling them.
+func (l *sqlLexer) skipQuoted(closing byte) {
+	l.pos++
+	for l.pos < len(l.sql) {
+		if l.sql[l.pos] == closing {
+			if l.peek(1) != closing {
+				l.pos++
+				return
+			}
+			l.pos++
+		}
+		l.pos++
+	}
+}
+
+// skipLineComment skips the -- comment at the current position up to the end of the line
+func (l *sqlLexer) skipLineComment() {
+	if i := strings.IndexByte(l.sql[l.pos:], '\n'); i >= 0 {
+		l.pos += i + 1
+		return
+	}
+	l.pos = len(l.sql)
+}
+
+// skipBlockComment skips the /* */ comment at the current position. Block comments may be nested, as in PostgreSQL
+// and SQL Server.
+func (l *sqlLexer) skipBlockComment() {
+	depth := 0
+	for l.pos < len(l.sql) {
+		switch {
+		case l.sql[l.pos] == '/' && l.peek(1) == '*':
+			depth++
+			l.pos += 2
+		case l.sql[l.pos] == '*' && l.peek(1) == '/':
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return
+			}
+		default:
+			l.pos++
+		}
+	}
+}
+
+// skipDollarQuoted skips the PostgreSQL dollar-quoted body, such as $$text$$ or $tag$text$tag$, at the current
+// position. A $ that does not start a dollar quote is skipped on its own.
+func (l *sqlLexer) skipDollarQuoted() {
+	// The tag follows the rules of an identifier, without a $ and not starting with a digit
+	end := l.pos + 1
+	for end < len(l.sql) && l.sql[end] != '$' && isIdentByte(l.sql[end]) {
+		if end == l.pos+1 && isDigitByte(l.sql[end]) {
+			break
+		}
+		end++
+	}
+
+	if end >= len(l.sql) || l.sql[end] != '$' {
+		l.pos++
+		return
+	}
+
+	tag := l.sql[l.pos : end+1]
+	if i := strings.Index(l.sql[end+1:], tag); i >= 0 {
+		l.pos = end + 1 + i + len(tag)
+		return
+	}
+	l.pos = len(l.sql)
+}
+
+// isEscapeString determines whether the quote at position i starts a PostgreSQL escape string, such as E'it\'s'
+func isEscapeString(sqlStr string, i int) bool {
+	return i > 0 && (sqlStr[i-1] == 'E' || sqlStr[i-1] == 'e') && (i < 2 || !isIdentByte(sqlStr[i-2]))
+}
+
+// isIdentByte determines whether the byte can be part of an unquoted identifier
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || isDigitByte(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// isDigitByte determines whether the byte is a decimal digit
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// countPlaceholders returns the number of ? parameter placeholders in the SQL statement written with the given syntax
+func countPlaceholders(sqlStr string, syntax sqlSyntax) int {
+	if strings.IndexByte(sqlStr, '?') < 0 {
+		return 0
+	}
+
+	n := 0
+	l := newSQLLexer(sqlStr, syntax)
+	for {
+		_, tok := l.next()
+		switch tok {
+		case tokenPlaceholder:
+			n++
+		case tokenEnd:
+			return n
+		}
+	}
+}
+
+// normalizePlaceholders converts the $1, $2, $3 placeholders of a WHERE or JOIN fragment to ? placeholders, ordering
+// the arguments to match. This allows fragments written with the placeholders of PostgreSQL to be combined, as the
+// placeholders are numbered again when the statement is generated. A numbered placeholder may be used more than once,
+// its argument is repeated. Fragments without numbered placeholders are returned unchanged. The quoted parts of the
+// fragment are read with the given syntax.
+//
+// ErrPlaceholderMismatch is returned when the placeholders do not match the arguments, or when the fragment mixes ?
+// and numbered placeholders.
+func normalizePlaceholders(sqlStr string, args []any, syntax sqlSyntax) (string, []any, error) {
+	if !strings.ContainsAny(sqlStr, "?$") {
+		if len(args) > 0 {
+			return "", nil, placeholderCountError(sqlStr, 0, len(args))
+		}
+		return sqlStr, args, nil
+	}
+
+	builder := new(strings.Builder)
+	builder.Grow(len(sqlStr))
+	ordered := make([]any, 0, len(args))
+	used := make([]bool, len(args))
+	positional := 0
+
+	l := newSQLLexer(sqlStr, syntax)
+	for {
+		text, tok := l.next()
+		builder.WriteString(text)
+		if tok == tokenEnd {
+			break
+		}
+
+		switch tok {
+		case tokenPlaceholder:
+			positional++
+			builder.WriteString(l.token())
+		case tokenEscapedMark:
+			builder.WriteString(l.token())
+		case tokenNumberedPlaceholder:
+			n, err := strconv.Atoi(l.token()[1:])
+			if err != nil || n < 1 || n > len(args) {
+				return "", nil, fmt.Errorf("%w: %q uses %s with %d arguments", ErrPlaceholderMismatch, sqlStr,
+					l.token(), len(args))
+			}
+
+			used[n-1] = true
+			ordered = append(ordered, args[n-1])
+			builder.WriteByte('?')
+		}
+	}
+
+	switch {
+	case len(ordered) == 0 && positional != len(args):
+		return "", nil, placeholderCountError(sqlStr, positional, len(args))
+	case len(ordered) == 0:
+		return sqlStr, args, nil
+	case positional > 0:
+		return "", nil, fmt.Errorf("%w: %q mixes ? and numbered placeholders", ErrPlaceholderMismatch, sqlStr)
+	}
+
+	for i, ok := range used {
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %q does not use the argument $%d", ErrPlaceholderMismatch, sqlStr, i+1)
+		}
+	}
+
+	return builder.String(), ordered, nil
+}
+
+// placeholderCountError returns the ErrPlaceholderMismatch reported when the number of ? placeholders of a fragment
+// differs from the number of its arguments
+func placeholderCountError(sqlStr string, placeholders, args int) error {
+	return fmt.Errorf("%w: %q has %d placeholders for %d arguments", ErrPlaceholderMismatch, sqlStr, placeholders, args)
+}
@@ -0,0 +1,494 @@
+package patcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebind_Lexer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sqlStr   string
+		expected string
+		count    int
+	}{
+		{
+			name:     "String literal",
+			sqlStr:   "note = '?' AND id = ?",
+			expected: "note = '?' AND id = $1",
+			count:    1,
+		},
+		{
+			name:     "Escaped quote in string literal",
+			sqlStr:   "note = 'it''s ?' AND id = ?",
+			expected: "note = 'it''s ?' AND id = $1",
+			count:    1,
+		},
+		{
+			name:     "Escape string",
+			sqlStr:   `note = E'it\'s ?' AND id = ?`,
+			expected: `note = E'it\'s ?' AND id = $1`,
+			count:    1,
+		},
+		{
+			name:     "Quoted identifiers",
+			sqlStr:   "\"what?\" = ? AND `why?` = ?",
+			expected: "\"what?\" = $1 AND `why?` = $2",
+			count:    2,
+		},
+		{
+			name:     "Line comment",
+			sqlStr:   "id = ? -- is it?\nAND age > ?",
+			expected: "id = $1 -- is it?\nAND age > $2",
+			count:    2,
+		},
+		{
+			name:     "Nested block comment",
+			sqlStr:   "id = ? /* why? /* because? */ still? */ AND age > ?",
+			expected: "id = $1 /* why? /* because? */ still? */ AND age > $2",
+			count:    2,
+		},
+		{
+			name:     "Dollar quoted",
+			sqlStr:   "note = $$what?$$ AND body = $tag$ $$ ? $tag$ AND id = ?",
+			expected: "note = $$what?$$ AND body = $tag$ $$ ? $tag$ AND id = $1",
+			count:    1,
+		},
+		{
+			name:     "JSONB operators",
+			sqlStr:   "tags ?| ? AND tags ?& ? AND tags ?? 'admin'",
+			expected: "tags ?| $1 AND tags ?& $2 AND tags ? 'admin'",
+			count:    2,
+		},
+		{
+			name:     "Placeholder followed by an operator",
+			sqlStr:   "name = ?|| 'suffix' AND flags = ?&& ?",
+			expected: "name = $1|| 'suffix' AND flags = $2&& $3",
+			count:    3,
+		},
+		{
+			name:     "Unterminated string",
+			sqlStr:   "id = ? AND note = 'what?",
+			expected: "id = $1 AND note = 'what?",
+			count:    1,
+		},
+		{
+			name:     "No placeholders",
+			sqlStr:   "id = 1",
+			expected: "id = 1",
+			count:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, DialectPostgreSQL.Rebind(tt.sqlStr))
+			require.Equal(t, tt.count, countPlaceholders(tt.sqlStr, sqlSyntax{}))
+		})
+	}
+}
+
+func TestGenerateSQL_LiteralQuestionMarks(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	teams := &joinStringOption{join: "JOIN teams t ON t.id = users.team_id AND t.tags ?| ?", args: []any{"admin"}}
+
+	sqlStr, args, err := NewSQLPatch(&user{ID: 1, Name: "John"},
+		WithTable("users"),
+		WithDialect(DialectPostgreSQL),
+		WithJoin(teams),
+		WithWhereStr("users.note <> '?' AND users.attrs ?? 'beta'"),
+	).GenerateSQL()
+	require.NoError(t, err)
+
+	require.Equal(t, "UPDATE users\n"+
+		"SET name = $1\n"+
+		"FROM teams t\n"+
+		"WHERE (1=1)\n"+
+		"AND (t.id = users.team_id AND t.tags ?| $2)\n"+
+		"AND (\n"+
+		"users.note <> '?' AND users.attrs ? 'beta'\n"+
+		")", sqlStr)
+	require.Equal(t, []any{"John", "admin"}, args)
+}
+
+func TestCountPlaceholders_BackslashEscapes(t *testing.T) {
+	t.Parallel()
+
+	mysql := dialectSyntax(DialectMySQL)
+	postgres := dialectSyntax(DialectPostgreSQL)
+
+	require.Equal(t, 1, countPlaceholders(`note = 'it\'s ?' AND id = ?`, mysql))
+	require.Equal(t, 1, countPlaceholders(`note = E'it\'s ?' AND id = ?`, postgres))
+	require.Equal(t, 2, countPlaceholders(`note = 'C:\' AND id = ? AND name = ?`, postgres))
+	require.Equal(t, 0, countPlaceholders(`note = 'C:\' AND id = ? AND name = ?`, mysql))
+	require.Equal(t, 2, countPlaceholders(`note = "it\"s ?" AND id = ? AND name = ?`, mysql))
+	require.Equal(t, 1, countPlaceholders(`note = "it\"s ?" AND id = ? AND name = ?`, postgres))
+}
+
+func TestCountPlaceholders_BracketIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	sqlServer := dialectSyntax(DialectSQLServer)
+
+	require.Equal(t, 2, countPlaceholders("[what?] = ? AND [a]]b?] = ?", sqlServer))
+	require.Equal(t, 4, countPlaceholders("[what?] = ? AND [a]]b?] = ?", dialectSyntax(DialectPostgreSQL)))
+	require.Equal(t, "[what?] = @p1 AND [a]]b?] = @p2", DialectSQLServer.Rebind("[what?] = ? AND [a]]b?] = ?"))
+	require.Equal(t, "ARRAY[$1] = $2", DialectPostgreSQL.Rebind("ARRAY[?] = ?"))
+}
+
+func TestNormalizePlaceholders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sqlStr   string
+		args     []any
+		syntax   sqlSyntax
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "Numbered placeholders",
+			sqlStr:   "a = $1 AND b = $2",
+			args:     []any{1, 2},
+			wantSQL:  "a = ? AND b = ?",
+			wantArgs: []any{1, 2},
+		},
+		{
+			name:     "Out of order",
+			sqlStr:   "a = $2 AND b = $1",
+			args:     []any{1, 2},
+			wantSQL:  "a = ? AND b = ?",
+			wantArgs: []any{2, 1},
+		},
+		{
+			name:     "Repeated",
+			sqlStr:   "a = $1 OR b = $1",
+			args:     []any{1},
+			wantSQL:  "a = ? OR b = ?",
+			wantArgs: []any{1, 1},
+		},
+		{
+			name:     "Literals and escapes",
+			sqlStr:   "note <> '$1' AND body <> $$ $2 $$ AND tags ?? 'a' AND price$1 = $1",
+			args:     []any{1},
+			wantSQL:  "note <> '$1' AND body <> $$ $2 $$ AND tags ?? 'a' AND price$1 = ?",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "Question mark placeholders",
+			sqlStr:   "a = ? AND b = ?",
+			args:     []any{1, 2},
+			wantSQL:  "a = ? AND b = ?",
+			wantArgs: []any{1, 2},
+		},
+		{
+			name:     "Backslash escapes",
+			sqlStr:   `note = 'it\'s $1' AND id = $1`,
+			args:     []any{1},
+			syntax:   sqlSyntax{backslashEscapes: true},
+			wantSQL:  `note = 'it\'s $1' AND id = ?`,
+			wantArgs: []any{1},
+		},
+		{
+			name:     "Double quoted string with backslash escapes",
+			sqlStr:   `note = "it\"s $1" AND id = $1`,
+			args:     []any{1},
+			syntax:   sqlSyntax{backslashEscapes: true},
+			wantSQL:  `note = "it\"s $1" AND id = ?`,
+			wantArgs: []any{1},
+		},
+		{
+			name:     "Bracket identifiers",
+			sqlStr:   "[price$1] = $1 AND [what?] <> 'x'",
+			args:     []any{1},
+			syntax:   sqlSyntax{bracketIdentifiers: true},
+			wantSQL:  "[price$1] = ? AND [what?] <> 'x'",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "Backslash without escapes",
+			sqlStr:   `note = 'C:\' AND id = $1`,
+			args:     []any{1},
+			wantSQL:  `note = 'C:\' AND id = ?`,
+			wantArgs: []any{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlStr, args, err := normalizePlaceholders(tt.sqlStr, tt.args, tt.syntax)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSQL, sqlStr)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestNormalizePlaceholders_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		sqlStr string
+		args   []any
+		msg    string
+	}{
+		{
+			name:   "Too few arguments",
+			sqlStr: "a = $1 AND b = $2",
+			args:   []any{1},
+			msg:    `"a = $1 AND b = $2" uses $2 with 1 arguments`,
+		},
+		{
+			name:   "Zero placeholder",
+			sqlStr: "a = $0",
+			args:   []any{1},
+			msg:    `"a = $0" uses $0 with 1 arguments`,
+		},
+		{
+			name:   "Unused argument",
+			sqlStr: "a = $2",
+			args:   []any{1, 2},
+			msg:    `"a = $2" does not use the argument $1`,
+		},
+		{
+			name:   "Mixed placeholders",
+			sqlStr: "a = $1 AND b = ?",
+			args:   []any{1, 2},
+			msg:    `"a = $1 AND b = ?" mixes ? and numbered placeholders`,
+		},
+		{
+			name:   "Too few question marks",
+			sqlStr: "a = ? AND b = ?",
+			args:   []any{1},
+			msg:    `"a = ? AND b = ?" has 2 placeholders for 1 arguments`,
+		},
+		{
+			name:   "Too many arguments",
+			sqlStr: "a = ?",
+			args:   []any{1, 2},
+			msg:    `"a = ?" has 1 placeholders for 2 arguments`,
+		},
+		{
+			name:   "No placeholders",
+			sqlStr: "a = 1",
+			args:   []any{1},
+			msg:    `"a = 1" has 0 placeholders for 1 arguments`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, err := normalizePlaceholders(tt.sqlStr, tt.args, sqlSyntax{})
+			require.ErrorIs(t, err, ErrPlaceholderMismatch)
+			require.ErrorContains(t, err, tt.msg)
+		})
+	}
+}
+
+func TestGenerateSQL_NumberedPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	teams := InnerJoin("teams").As("t").On(
+		&whereStringOption{where: "t.id = users.team_id AND t.name = $1", args: []any{"team"}},
+	)
+
+	filter := NewMultiFilter()
+	filter.Add(&whereStringOption{where: "age > $1", args: []any{18}})
+	filter.Add(OrGroup(
+		&whereStringOption{where: "role = $1", args: []any{"admin"}},
+		&whereStringOption{where: "owner_id = $1 OR editor_id = $1", args: []any{7}},
+	))
+
+	tests := []struct {
+		name     string
+		dialect  SQLDialect
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:    "MySQL",
+			dialect: DialectMySQL,
+			wantSQL: "UPDATE users\n" +
+				"INNER JOIN teams AS t ON (t.id = users.team_id AND t.name = ?)\n" +
+				"SET name = ?\n" +
+				"WHERE (1=1)\n" +
+				"AND (\n" +
+				"(age > ?\nAND (role = ? OR (owner_id = ? OR editor_id = ?)))\n" +
+				")",
+			wantArgs: []any{"team", "John", 18, "admin", 7, 7},
+		},
+		{
+			name:    "PostgreSQL",
+			dialect: DialectPostgreSQL,
+			wantSQL: "UPDATE users\n" +
+				"SET name = $1\n" +
+				"FROM teams AS t\n" +
+				"WHERE (1=1)\n" +
+				"AND ((t.id = users.team_id AND t.name = $2))\n" +
+				"AND (\n" +
+				"(age > $3\nAND (role = $4 OR (owner_id = $5 OR editor_id = $6)))\n" +
+				")",
+			wantArgs: []any{"John", "team", 18, "admin", 7, 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlStr, args, err := NewSQLPatch(&user{Name: "John"},
+				WithTable("users"),
+				WithDialect(tt.dialect),
+				WithJoin(teams),
+				WithWhere(filter),
+			).GenerateSQL()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSQL, sqlStr)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestGenerateSQL_NumberedPlaceholders_Mismatch(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	filter := NewMultiFilter()
+	filter.Add(&whereStringOption{where: "age > $2", args: []any{18}})
+
+	tests := []struct {
+		name string
+		opt  PatchOpt
+	}{
+		{name: "Where", opt: WithWhereStr("id = $2", 1)},
+		{name: "Question marks", opt: WithWhereStr("id = ? AND y = ?", 1)},
+		{name: "Join question marks", opt: WithJoinStr("JOIN teams t ON t.id = ?")},
+		{name: "Join", opt: WithJoinStr("JOIN teams t ON t.id = $1")},
+		{name: "Join condition", opt: WithJoin(InnerJoin("teams", &whereStringOption{where: "teams.id = $1"}))},
+		{name: "Group", opt: WithWhere(AndGroup(&whereStringOption{where: "id = $1"}))},
+		{name: "MultiFilter", opt: WithWhere(filter)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, err := NewSQLPatch(&user{Name: "John"},
+				WithTable("users"),
+				WithWhereStr("1=1"),
+				tt.opt,
+			).GenerateSQL()
+			require.ErrorIs(t, err, ErrPlaceholderMismatch)
+		})
+	}
+}
+
+func TestGenerateSQL_BackslashEscapes(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	tests := []struct {
+		name     string
+		opts     []PatchOpt
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name: "MySQL",
+			opts: []PatchOpt{
+				WithWhereStr(`note = 'it\'s $1' AND id = $1`, 1),
+			},
+			wantSQL: "UPDATE users\n" +
+				"SET name = ?\n" +
+				"WHERE (1=1)\n" +
+				"AND (\n" +
+				"note = 'it\\'s $1' AND id = ?\n" +
+				")",
+			wantArgs: []any{"John", 1},
+		},
+		{
+			name: "MySQL double quoted string",
+			opts: []PatchOpt{
+				WithWhereStr(`note = "it\"s ?" AND id = ?`, 1),
+			},
+			wantSQL: "UPDATE users\n" +
+				"SET name = ?\n" +
+				"WHERE (1=1)\n" +
+				"AND (\n" +
+				"note = \"it\\\"s ?\" AND id = ?\n" +
+				")",
+			wantArgs: []any{"John", 1},
+		},
+		{
+			name: "SQL Server bracket identifiers",
+			opts: []PatchOpt{
+				WithWhereStr("[what?] = ?", 1),
+				WithDialect(DialectSQLServer),
+			},
+			wantSQL: "UPDATE users\n" +
+				"SET name = @p1\n" +
+				"WHERE (1=1)\n" +
+				"AND (\n" +
+				"[what?] = @p2\n" +
+				")",
+			wantArgs: []any{"John", 1},
+		},
+		{
+			name: "PostgreSQL set after the filter",
+			opts: []PatchOpt{
+				WithWhereStr(`note = 'C:\' AND id = $1`, 1),
+				WithDialect(DialectPostgreSQL),
+			},
+			wantSQL: "UPDATE users\n" +
+				"SET name = $1\n" +
+				"WHERE (1=1)\n" +
+				"AND (\n" +
+				"note = 'C:\\' AND id = $2\n" +
+				")",
+			wantArgs: []any{"John", 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlStr, args, err := NewSQLPatch(&user{Name: "John"},
+				append([]PatchOpt{WithTable("users")}, tt.opts...)...,
+			).GenerateSQL()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSQL, sqlStr)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
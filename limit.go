@@ -0,0 +1,73 @@
+package patcher
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrLimitUnsupported is returned when a limit is requested for an update that the SQL dialect cannot limit
+var ErrLimitUnsupported = errors.New("limit is not supported by the SQL dialect")
+
+// UpdateLimitStyle is the way a dialect limits the number of rows changed by an UPDATE statement
+type UpdateLimitStyle int
+
+const (
+	// UpdateLimitUnsupported is used by dialects that cannot limit the rows changed by an UPDATE statement
+	UpdateLimitUnsupported UpdateLimitStyle = iota
+
+	// UpdateLimitClause appends a LIMIT clause, which cannot be used in an UPDATE statement with joins, as in MySQL
+	UpdateLimitClause
+
+	// UpdateLimitTop writes UPDATE TOP (n), as in SQL Server
+	UpdateLimitTop
+
+	// UpdateLimitRowNum limits the ROWNUM in the where clause, as in Oracle
+	UpdateLimitRowNum
+)
+
+// UpdateLimitStyle returns the way the number of rows changed by an UPDATE statement is limited in the dialect
+func (d SQLDialect) UpdateLimitStyle() UpdateLimitStyle {
+	switch d {
+	case DialectMySQL:
+		return UpdateLimitClause
+	case DialectSQLServer:
+		return UpdateLimitTop
+	case DialectOracle:
+		return UpdateLimitRowNum
+	default:
+		return UpdateLimitUnsupported
+	}
+}
+
+// updateLimit is the SQL limiting the number of rows changed by an update
+type updateLimit struct {
+	// top is written between the UPDATE keyword and the table, as in SQL Server
+	top string
+
+	// suffix is written after the where clause
+	suffix string
+}
+
+// limitClause returns the SQL limiting the update to the configured number of rows
+func (s *SQLPatch) limitClause(hasJoins bool) (updateLimit, error) {
+	if s.limit <= 0 {
+		return updateLimit{}, nil
+	}
+
+	limit := strconv.Itoa(s.limit)
+
+	switch s.dialect.UpdateLimitStyle() {
+	case UpdateLimitClause:
+		if hasJoins {
+			return updateLimit{}, fmt.Errorf("%w: LIMIT cannot be used in an UPDATE with joins", ErrLimitUnsupported)
+		}
+		return updateLimit{suffix: "LIMIT " + limit}, nil
+	case UpdateLimitTop:
+		return updateLimit{top: "TOP (" + limit + ") "}, nil
+	case UpdateLimitRowNum:
+		return updateLimit{suffix: "AND ROWNUM <= " + limit}, nil
+	default:
+		return updateLimit{}, ErrLimitUnsupported
+	}
+}
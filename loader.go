@@ -3,6 +3,8 @@ package patcher
 import (
 	"errors"
 	"reflect"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
 )
 
 var (
@@ -18,8 +20,17 @@ var (
 //
 // This function is useful if you are inserting a patch into an existing object but require a new object to be returned with
 // all fields updated.
+//
+// Resources implementing DiffLoader, such as those with builders generated by patchergen, are loaded without
+// reflection when the options do not change which fields are loaded.
 func LoadDiff[T any](old, newT *T, opts ...PatchOpt) error {
-	return newPatchDefaults(opts...).loadDiff(old, newT)
+	s := newPatchDefaults(opts...)
+	if loader, ok := any(old).(DiffLoader[T]); ok && old != nil && newT != nil && s.usesDefaultFields() {
+		loader.LoadDiffFrom(newT)
+		return nil
+	}
+
+	return s.loadDiff(old, newT)
 }
 
 // loadDiff inserts the fields provided in the new struct pointer into the old struct pointer and injects the new
@@ -31,10 +42,24 @@ func (s *SQLPatch) loadDiff(old, newT any) error {
 		return ErrInvalidType
 	}
 
-	oElem := reflect.ValueOf(old).Elem()
-	nElem := reflect.ValueOf(newT).Elem()
+	if err := s.resolveFieldMask(reflect.TypeOf(old).Elem()); err != nil {
+		return err
+	}
+
+	return s.loadDiffPath(reflect.ValueOf(old).Elem(), reflect.ValueOf(newT).Elem(), "")
+}
+
+// loadDiffPath loads the diff for the struct values, tracking the path of the nested struct so that the field mask
+// can be applied.
+func (s *SQLPatch) loadDiffPath(oElem, nElem reflect.Value, path string) error {
+	if oElem.Kind() != reflect.Struct {
+		return ErrInvalidType
+	}
+
+	fields := fieldmeta.TypeFields(oElem.Type(), s.tagName)
 
-	for i := range oElem.NumField() {
+	for i := range fields {
+		meta := &fields[i]
 		oField := oElem.Field(i)
 		nField := nElem.Field(i)
 
@@ -43,37 +68,53 @@ func (s *SQLPatch) loadDiff(old, newT any) error {
 			continue
 		}
 
-		oldField := oElem.Type().Field(i)
+		fieldPath := s.fieldPath(path, meta.Field.Name)
 
 		// Handle embedded structs (Anonymous fields)
-		if oldField.Anonymous {
-			if err := s.handleEmbeddedStruct(oField, nField, oldField.Tag.Get(s.tagName)); err != nil {
+		if meta.Field.Anonymous {
+			if err := s.handleEmbeddedStruct(oField, nField, meta.ColumnOptions, fieldPath); err != nil {
 				return err
 			}
 			continue
 		}
 
-		// If the field is a struct, we need to recursively call LoadDiff
-		if oField.Kind() == reflect.Struct {
-			if err := s.loadDiff(oField.Addr().Interface(), nField.Addr().Interface()); err != nil {
+		// If the field is a struct, we need to recursively call LoadDiff. Structs that are stored as a single
+		// value, such as time.Time, are compared as a whole.
+		if meta.Nested {
+			if err := s.loadDiffPath(oField, nField, s.fieldPath(fieldPath, fieldMaskSeparator)); err != nil {
 				return err
 			}
 			continue
 		}
 
 		// See if the field should be ignored.
-		if s.checkSkipField(&oldField) {
+		if s.checkSkipField(meta) || s.isMaskedOut(fieldPath) {
 			continue
 		}
 
-		patcherOptsTag := oldField.Tag.Get(TagOptsName)
+		// An Optional is loaded whenever it is set, regardless of whether it holds NULL or the zero value
+		if meta.Optional {
+			if opt, _ := nField.Interface().(OptionalValue); opt.IsSet() {
+				oField.Set(nField)
+			}
+			continue
+		}
+
+		// A driver.Valuer resolving to NULL, such as sql.NullString with Valid set to false, is only loaded when
+		// nil values are requested.
+		if meta.Valuer && (nField.Kind() == reflect.Ptr || !nField.IsZero()) && isNullValuer(nField) {
+			if s.shouldIncludeNil(meta.Options) {
+				oField.Set(nField)
+			}
+			continue
+		}
 
 		// Compare the old and new fields.
 		//
 		// New fields take priority over old fields if they are provided based on the configuration.
-		if nField.Kind() != reflect.Ptr && (!nField.IsZero() || s.shouldIncludeZero(patcherOptsTag)) {
+		if nField.Kind() != reflect.Ptr && (!nField.IsZero() || s.shouldIncludeZero(meta.Options)) {
 			oField.Set(nField)
-		} else if nField.Kind() == reflect.Ptr && (!nField.IsNil() || s.shouldIncludeNil(patcherOptsTag)) {
+		} else if nField.Kind() == reflect.Ptr && (!nField.IsNil() || s.shouldIncludeNil(meta.Options)) {
 			oField.Set(nField)
 		}
 	}
@@ -81,16 +122,23 @@ func (s *SQLPatch) loadDiff(old, newT any) error {
 	return nil
 }
 
-func (s *SQLPatch) handleEmbeddedStruct(oField, nField reflect.Value, tag string) error {
+func (s *SQLPatch) handleEmbeddedStruct(oField, nField reflect.Value, opts fieldmeta.TagOptions, path string) error {
+	nestedPath := s.fieldPath(path, fieldMaskSeparator)
 	if oField.Kind() != reflect.Ptr {
-		return s.loadDiff(oField.Addr().Interface(), nField.Addr().Interface())
+		return s.loadDiffPath(oField, nField, nestedPath)
 	}
 
 	switch {
 	case !oField.IsNil() && !nField.IsNil():
-		return s.loadDiff(oField.Interface(), nField.Interface())
+		return s.loadDiffPath(oField.Elem(), nField.Elem(), nestedPath)
+	case s.maskPaths != nil && !nField.IsNil():
+		// Only the masked fields of the new struct are loaded into a newly allocated struct
+		oField.Set(reflect.New(oField.Type().Elem()))
+		return s.loadDiffPath(oField.Elem(), nField.Elem(), nestedPath)
+	case s.isMaskedOut(path):
+		return nil
 	case nField.IsValid() && !nField.IsNil(),
-		nField.IsNil() && s.shouldIncludeNil(tag):
+		nField.IsNil() && s.shouldIncludeNil(opts):
 		oField.Set(nField)
 	}
 
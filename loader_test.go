@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -450,7 +451,7 @@ func (s *loadDiffSuite) TestHandleEmbeddedStruct_ValidNonNilField() {
 		Age:  26,
 	}
 
-	err := s.patch.handleEmbeddedStruct(reflect.ValueOf(&old).Elem().FieldByName("Embedded"), reflect.ValueOf(&n).Elem().FieldByName("Embedded"), "")
+	err := s.patch.handleEmbeddedStruct(reflect.ValueOf(&old).Elem().FieldByName("Embedded"), reflect.ValueOf(&n).Elem().FieldByName("Embedded"), 0, "Embedded")
 	s.Require().NoError(err)
 	s.Equal("Some description", old.Description)
 }
@@ -988,3 +989,32 @@ func (s *loadDiffSuite) TestLoadDiff_IgnoreTags() {
 	s.Equal("some address", old.Addr)
 	s.Equal("some other email", old.Email)
 }
+
+func (s *loadDiffSuite) TestLoadDiff_Success_Time() {
+	type testStruct struct {
+		Name      string
+		UpdatedAt time.Time
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	old := testStruct{Name: "John"}
+	n := testStruct{UpdatedAt: now}
+
+	err := s.patch.loadDiff(&old, &n)
+	s.Require().NoError(err)
+	s.Equal("John", old.Name)
+	s.Equal(now, old.UpdatedAt)
+}
+
+func BenchmarkLoadDiff(b *testing.B) {
+	newUser := benchmarkUser{Name: "Jane", Nickname: ptr("jj"), Address: benchmarkAddress{City: "Paris"}}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		old := newBenchmarkUser()
+		if err := LoadDiff(old, &newUser); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
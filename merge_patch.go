@@ -0,0 +1,468 @@
+package patcher
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
+	"github.com/jacobbrewer1/patcher/internal/patchhook"
+)
+
+func init() {
+	patchhook.RejectPrimaryKeys = func(patch any) {
+		patch.(*SQLPatch).rejectPrimaryKeys = true
+	}
+}
+
+const jsonTagName = "json"
+
+var (
+	// ErrInvalidMergePatch is returned when a merge patch document is not a JSON object
+	ErrInvalidMergePatch = errors.New("invalid merge patch: document must be a JSON object")
+
+	// ErrUnknownField is returned when a patch document references a field that does not exist on the resource
+	ErrUnknownField = errors.New("unknown field")
+
+	// ErrUnsupportedField is returned when a patch document sets a field whose type cannot be stored as a database field
+	ErrUnsupportedField = errors.New("unsupported field type")
+
+	scannerType         = reflect.TypeFor[sql.Scanner]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+)
+
+// UnknownFieldsError is returned when a patch document contains keys that do not map onto any field of the resource.
+// Nested keys are reported as dot separated paths, for example "address.zip".
+type UnknownFieldsError struct {
+	Paths []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnknownField, strings.Join(e.Paths, ", "))
+}
+
+func (e *UnknownFieldsError) Unwrap() error {
+	return ErrUnknownField
+}
+
+// UnsupportedFieldError is returned when a patch document sets a field whose type cannot be stored as a database
+// field, such as a slice or map not tagged with the json option, or a nested struct that is not flattened.
+type UnsupportedFieldError struct {
+	Path string
+	Type reflect.Type
+}
+
+func (e *UnsupportedFieldError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", ErrUnsupportedField, e.Path, e.Type)
+}
+
+func (e *UnsupportedFieldError) Unwrap() error {
+	return ErrUnsupportedField
+}
+
+// NewSQLPatchFromMergePatch creates a new SQLPatch from a JSON Merge Patch (RFC 7396) document.
+//
+// The keys of the document are matched against the json tags of T (falling back to the field name, in the same way as
+// encoding/json) and only the keys present in the document produce SET clauses. A JSON null is written as SQL NULL,
+// regardless of the zero and nil value options. Nested objects are merged into flattened structs, and keys that do not
+// map onto any field are rejected with an UnknownFieldsError. Keys setting a field whose type cannot be stored as a
+// database field are rejected with an UnsupportedFieldError.
+func NewSQLPatchFromMergePatch[T any](body []byte, opts ...PatchOpt) (*SQLPatch, error) {
+	typeOf := reflect.TypeFor[T]()
+	if typeOf.Kind() == reflect.Ptr {
+		typeOf = typeOf.Elem()
+	}
+
+	if typeOf.Kind() != reflect.Struct {
+		return nil, ErrInvalidType
+	}
+
+	doc, err := decodeMergeObject(body)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := newPatchDefaults(opts...)
+	if patch.table == "" {
+		patch.table = toSnakeCase(typeOf.Name())
+	}
+
+	unknown := make([]string, 0)
+	if err := patch.mergePatchGen(doc, typeOf, "", "", &unknown, patch.mergeSetGen); err != nil {
+		return nil, err
+	}
+
+	if err := patch.mergeConditionsGen(typeOf, &unknown); err != nil {
+		return nil, err
+	}
+
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return nil, &UnknownFieldsError{Paths: unknown}
+	}
+
+	patch.timestampGen(typeOf, "")
+	patch.setGen()
+	patch.primaryKeyWhere()
+
+	if patch.genErr != nil {
+		return nil, patch.genErr
+	}
+
+	return patch, nil
+}
+
+// mergeColumn is a column resolved from a merge patch document
+type mergeColumn struct {
+	// meta is the metadata of the field of the column
+	meta *fieldmeta.FieldMeta
+
+	// column is the prefixed and unquoted column name
+	column string
+
+	// value is the decoded value of the field, or the zero reflect.Value for a JSON null
+	value reflect.Value
+}
+
+// mergeColumnFunc is called for every column resolved from a merge patch document
+type mergeColumnFunc func(col *mergeColumn)
+
+// mergePatchGen resolves the keys of the merge patch document onto the columns of the struct type, calling gen for
+// every column and recording any keys that do not map onto a field of the struct type.
+func (s *SQLPatch) mergePatchGen(
+	doc map[string]json.RawMessage,
+	typeOf reflect.Type,
+	prefix, path string,
+	unknown *[]string,
+	gen mergeColumnFunc,
+) error {
+	consumed := make(map[string]struct{}, len(doc))
+	if err := s.mergeStructFields(doc, typeOf, prefix, path, consumed, unknown, gen); err != nil {
+		return err
+	}
+
+	for key := range doc {
+		if _, ok := consumed[key]; !ok {
+			*unknown = append(*unknown, path+key)
+		}
+	}
+
+	return nil
+}
+
+// mergeStructFields walks the fields of the struct type in order, resolving the column of every field present in the
+// document. Embedded structs without a json name have their fields promoted, as encoding/json does.
+func (s *SQLPatch) mergeStructFields(
+	doc map[string]json.RawMessage,
+	typeOf reflect.Type,
+	prefix, path string,
+	consumed map[string]struct{},
+	unknown *[]string,
+	gen mergeColumnFunc,
+) error {
+	fields := fieldmeta.TypeFields(typeOf, s.tagName)
+	for i := range fields {
+		meta := &fields[i]
+		structField := meta.Field
+		if !structField.IsExported() || s.checkSkipField(meta) {
+			continue
+		}
+
+		name, hasName, ok := jsonFieldName(&structField)
+		if !ok {
+			continue
+		}
+
+		nestedPrefix, flatten := meta.Prefix, meta.Flatten
+		nestedType := structField.Type
+		if nestedType.Kind() == reflect.Ptr {
+			nestedType = nestedType.Elem()
+		}
+
+		if flatten && structField.Anonymous && !hasName {
+			if err := s.mergeStructFields(doc, nestedType, prefix+nestedPrefix, path, consumed, unknown, gen); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key, raw, found := lookupMergeKey(doc, name, consumed)
+		if !found {
+			continue
+		}
+		consumed[key] = struct{}{}
+
+		if flatten {
+			if isJSONNull(raw) {
+				s.mergeNullFields(nestedType, prefix+nestedPrefix, gen)
+				continue
+			}
+
+			nestedDoc, err := decodeMergeObject(raw)
+			if err != nil {
+				return fmt.Errorf("decode field %s: %w", path+key, err)
+			}
+
+			if err := s.mergePatchGen(nestedDoc, nestedType, prefix+nestedPrefix, path+key+".", unknown, gen); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !mergeFieldSupported(meta) {
+			return &UnsupportedFieldError{Path: path + key, Type: structField.Type}
+		}
+
+		col := &mergeColumn{
+			meta:   meta,
+			column: prefix + meta.Column,
+		}
+
+		if !isJSONNull(raw) {
+			value, err := decodeMergeValue(raw, structField.Type)
+			if err != nil {
+				return fmt.Errorf("decode field %s: %w", path+key, err)
+			}
+			col.value = value
+		}
+
+		gen(col)
+	}
+
+	return nil
+}
+
+// mergeNullFields resolves every column of the flattened struct type to a JSON null
+func (s *SQLPatch) mergeNullFields(typeOf reflect.Type, prefix string, gen mergeColumnFunc) {
+	fields := fieldmeta.TypeFields(typeOf, s.tagName)
+	for i := range fields {
+		meta := &fields[i]
+		if !meta.Field.IsExported() || s.checkSkipField(meta) {
+			continue
+		}
+
+		if meta.Flatten {
+			nestedType := meta.Field.Type
+			if nestedType.Kind() == reflect.Ptr {
+				nestedType = nestedType.Elem()
+			}
+
+			s.mergeNullFields(nestedType, prefix+meta.Prefix, gen)
+			continue
+		}
+
+		gen(&mergeColumn{
+			meta:   meta,
+			column: prefix + meta.Column,
+		})
+	}
+}
+
+// mergeSetGen generates the SET clause component of the column. A JSON null is written as NULL, except for the
+// version field which is never set from the document. Primary keys generate the where clause, or are rejected when
+// the patch is configured with patchhook.RejectPrimaryKeys.
+func (s *SQLPatch) mergeSetGen(col *mergeColumn) {
+	meta := col.meta
+	if s.hasSet(col.column) || meta.Options.Has(fieldmeta.OptAutoCreate|fieldmeta.OptAutoUpdate) {
+		return
+	}
+
+	switch {
+	case meta.ColumnOptions.Has(fieldmeta.OptPrimaryKey) && s.rejectPrimaryKeys:
+		if s.genErr == nil {
+			s.genErr = fmt.Errorf("%w: %s", patchhook.ErrPrimaryKeyWrite, col.column)
+		}
+	case meta.ColumnOptions.Has(fieldmeta.OptPrimaryKey):
+		s.primaryKeyGen(meta, col.value, col.column)
+	case meta.Options.Has(fieldmeta.OptVersion):
+		if col.value.IsValid() {
+			s.versionGen(meta, col.value, col.column)
+		}
+	case !col.value.IsValid():
+		s.fields = append(s.fields, s.quote(col.column)+" = ?")
+		s.args = append(s.args, nil)
+	default:
+		s.fieldGen(meta, col.column, s.fieldArg(meta, col.value))
+	}
+}
+
+// mergeConditionsGen resolves the documents given with WithMergeConditions against the struct type, collecting the
+// paths that do not map onto any field in unknown.
+func (s *SQLPatch) mergeConditionsGen(typeOf reflect.Type, unknown *[]string) error {
+	for _, body := range s.mergeConditions {
+		doc, err := decodeMergeObject(body)
+		if err != nil {
+			return err
+		}
+
+		if err := s.mergePatchGen(doc, typeOf, "", "", unknown, s.mergeConditionGen); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// conditionsGen resolves the merge conditions of a patch built from a struct, recording the first error encountered
+func (s *SQLPatch) conditionsGen(typeOf reflect.Type) {
+	unknown := make([]string, 0)
+	err := s.mergeConditionsGen(typeOf, &unknown)
+	if err == nil && len(unknown) > 0 {
+		slices.Sort(unknown)
+		err = &UnknownFieldsError{Paths: unknown}
+	}
+
+	if err != nil && s.genErr == nil {
+		s.genErr = err
+	}
+}
+
+// mergeConditionGen generates the condition comparing the column against its value. Values resolving to NULL are
+// compared using IS NULL.
+func (s *SQLPatch) mergeConditionGen(col *mergeColumn) {
+	var arg any
+	if col.value.IsValid() {
+		arg = s.fieldArg(col.meta, col.value)
+	}
+
+	if arg == nil {
+		s.conditions = append(s.conditions, s.quote(col.column)+" IS NULL")
+		return
+	}
+
+	s.conditions = append(s.conditions, s.quote(col.column)+" = ?")
+	s.conditionArgs = append(s.conditionArgs, arg)
+}
+
+// mergeFieldSupported checks if the value of the field can be decoded from a merge patch and bound as a single
+// argument. Fields tagged with the json option, driver.Valuer implementations and Optional values are always supported.
+func mergeFieldSupported(meta *fieldmeta.FieldMeta) bool {
+	if meta.Options.Has(fieldmeta.OptJSON) || meta.Valuer || meta.Optional {
+		return true
+	}
+
+	if !meta.ValidType || meta.Expression {
+		return false
+	}
+
+	typeOf := meta.Field.Type
+	if typeOf.Kind() == reflect.Ptr {
+		typeOf = typeOf.Elem()
+	}
+
+	switch typeOf.Kind() {
+	case reflect.Ptr:
+		return false
+	case reflect.Struct:
+		return fieldmeta.IsScalarStruct(typeOf)
+	default:
+		return fieldmeta.ValidKind(typeOf.Kind())
+	}
+}
+
+// decodeMergeObject decodes a merge patch document, which must be a JSON object
+func decodeMergeObject(raw []byte) (map[string]json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, ErrInvalidMergePatch
+	}
+
+	doc := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(trimmed, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMergePatch, err)
+	}
+
+	return doc, nil
+}
+
+// decodeMergeValue decodes the raw JSON value into a new value of the given type. Types implementing sql.Scanner but
+// not json.Unmarshaler, such as sql.NullString, are decoded by scanning the plain JSON value.
+func decodeMergeValue(raw json.RawMessage, typeOf reflect.Type) (reflect.Value, error) {
+	baseType := typeOf
+	if baseType.Kind() == reflect.Ptr {
+		baseType = baseType.Elem()
+	}
+
+	ptrType := reflect.PointerTo(baseType)
+	if !ptrType.Implements(scannerType) || ptrType.Implements(jsonUnmarshalerType) {
+		target := reflect.New(typeOf)
+		if err := json.Unmarshal(raw, target.Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+		return target.Elem(), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var src any
+	if err := decoder.Decode(&src); err != nil {
+		return reflect.Value{}, err
+	}
+
+	if number, ok := src.(json.Number); ok {
+		src = number.String()
+	}
+
+	target := reflect.New(baseType)
+	scanner, ok := target.Interface().(sql.Scanner)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%s does not implement sql.Scanner", baseType)
+	}
+
+	if err := scanner.Scan(src); err != nil {
+		return reflect.Value{}, err
+	}
+
+	if typeOf.Kind() == reflect.Ptr {
+		return target, nil
+	}
+
+	return target.Elem(), nil
+}
+
+// jsonFieldName returns the name used for the field by encoding/json, whether the name was set explicitly by the json
+// tag, and false if the field is excluded from JSON.
+func jsonFieldName(field *reflect.StructField) (name string, explicit, ok bool) {
+	tag := field.Tag.Get(jsonTagName)
+	if tag == TagOptSkip {
+		return "", false, false
+	}
+
+	name, _, _ = strings.Cut(tag, TagOptSeparator)
+	if name == "" {
+		return field.Name, false, true
+	}
+
+	return name, true, true
+}
+
+// lookupMergeKey finds the key in the document matching the field name. An exact match is preferred, falling back to
+// a case-insensitive match in the same way as encoding/json.
+func lookupMergeKey(doc map[string]json.RawMessage, name string, consumed map[string]struct{}) (string, json.RawMessage, bool) {
+	if raw, ok := doc[name]; ok {
+		return name, raw, true
+	}
+
+	for key, raw := range doc {
+		if _, ok := consumed[key]; ok {
+			continue
+		}
+
+		if strings.EqualFold(key, name) {
+			return key, raw, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// isJSONNull checks if the raw JSON value is null
+func isJSONNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
@@ -0,0 +1,236 @@
+package patcher
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type mergePatchSuite struct {
+	suite.Suite
+}
+
+func TestMergePatchSuite(t *testing.T) {
+	suite.Run(t, new(mergePatchSuite))
+}
+
+type MergeBase struct {
+	CreatedBy *string `db:"created_by" json:"created_by"`
+}
+
+type mergeAddress struct {
+	City   *string `db:"city" json:"city"`
+	Street *string `db:"street" json:"street"`
+}
+
+type mergeUser struct {
+	MergeBase
+	ID       *int           `db:"id" json:"id" patcher:"-"`
+	Name     *string        `db:"name" json:"name"`
+	Email    string         `db:"email" json:"email,omitempty"`
+	Age      int            `db:"age"`
+	Nick     sql.NullString `db:"nick" json:"nick"`
+	Tags     []string       `db:"tags" json:"tags" patcher:"json"`
+	Address  mergeAddress   `json:"address" patcher:"prefix=addr_"`
+	Internal string         `db:"internal" json:"-"`
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch() {
+	body := []byte(`{"name": "john", "email": "", "Age": 0, "nick": "jj", "tags": ["a"]}`)
+
+	patch, err := NewSQLPatchFromMergePatch[mergeUser](body, WithWhereStr("id = ?", 1))
+	s.Require().NoError(err)
+
+	s.Equal([]string{"name = ?", "email = ?", "age = ?", "nick = ?", "tags = ?"}, patch.fields)
+	s.Equal([]any{"john", "", 0, "jj", `["a"]`}, patch.args)
+	s.Equal("merge_user", patch.table)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_Null() {
+	body := []byte(`{"name": null, "nick": null, "created_by": null}`)
+
+	patch, err := NewSQLPatchFromMergePatch[mergeUser](body)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"created_by = ?", "name = ?", "nick = ?"}, patch.fields)
+	s.Equal([]any{nil, nil, nil}, patch.args)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_Nested() {
+	body := []byte(`{"address": {"city": "London"}}`)
+
+	patch, err := NewSQLPatchFromMergePatch[mergeUser](body)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"addr_city = ?"}, patch.fields)
+	s.Equal([]any{"London"}, patch.args)
+
+	patch, err = NewSQLPatchFromMergePatch[mergeUser]([]byte(`{"address": null}`))
+	s.Require().NoError(err)
+
+	s.Equal([]string{"addr_city = ?", "addr_street = ?"}, patch.fields)
+	s.Equal([]any{nil, nil}, patch.args)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_GenerateSQL() {
+	body := []byte(`{"name": "john", "nick": null}`)
+
+	patch, err := NewSQLPatchFromMergePatch[mergeUser](body,
+		WithTable("users"),
+		WithWhereStr("id = ?", 1),
+	)
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, nick = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"john", nil, 1}, args)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_UnknownFields() {
+	body := []byte(`{"name": "john", "id": 1, "internal": "x", "unknown": true, "address": {"zip": "123"}}`)
+
+	patch, err := NewSQLPatchFromMergePatch[mergeUser](body)
+	s.Require().ErrorIs(err, ErrUnknownField)
+	s.Nil(patch)
+
+	var unknownErr *UnknownFieldsError
+	s.Require().ErrorAs(err, &unknownErr)
+	s.Equal([]string{"address.zip", "id", "internal", "unknown"}, unknownErr.Paths)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_InvalidDocument() {
+	_, err := NewSQLPatchFromMergePatch[mergeUser]([]byte(`["name"]`))
+	s.Require().ErrorIs(err, ErrInvalidMergePatch)
+
+	_, err = NewSQLPatchFromMergePatch[mergeUser]([]byte(`{"address": "London"}`))
+	s.Require().ErrorIs(err, ErrInvalidMergePatch)
+
+	_, err = NewSQLPatchFromMergePatch[mergeUser]([]byte(`{"age": "old"}`))
+	s.Require().Error(err)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_Version() {
+	type versioned struct {
+		Name    *string `db:"name" json:"name"`
+		Version int     `db:"version" json:"version" patcher:"version"`
+	}
+
+	patch, err := NewSQLPatchFromMergePatch[versioned]([]byte(`{"name": "john", "version": 4}`))
+	s.Require().NoError(err)
+
+	s.Equal([]string{"name = ?", "version = version + 1"}, patch.fields)
+	s.Equal("version", patch.versionColumn)
+	s.Equal(4, patch.versionArg)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_NotStruct() {
+	_, err := NewSQLPatchFromMergePatch[string]([]byte(`{}`))
+	s.Require().ErrorIs(err, ErrInvalidType)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_UnsupportedField() {
+	type unsupported struct {
+		Name    *string           `db:"name" json:"name"`
+		Tags    []string          `db:"tags" json:"tags"`
+		Labels  map[string]string `db:"labels" json:"labels"`
+		Address *mergeAddress     `db:"address" json:"address"`
+		Meta    map[string]string `db:"meta" json:"meta" patcher:"json"`
+	}
+
+	tests := []struct {
+		name string
+		body string
+		path string
+	}{
+		{"slice", `{"name": "john", "tags": ["a"]}`, "tags"},
+		{"map", `{"labels": {"a": "b"}}`, "labels"},
+		{"nested struct", `{"address": {"city": "London"}}`, "address"},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			patch, err := NewSQLPatchFromMergePatch[unsupported]([]byte(tt.body))
+			s.Require().ErrorIs(err, ErrUnsupportedField)
+			s.Nil(patch)
+
+			var unsupportedErr *UnsupportedFieldError
+			s.Require().ErrorAs(err, &unsupportedErr)
+			s.Equal(tt.path, unsupportedErr.Path)
+		})
+	}
+
+	patch, err := NewSQLPatchFromMergePatch[unsupported]([]byte(`{"meta": {"a": "b"}}`))
+	s.Require().NoError(err)
+
+	s.Equal([]string{"meta = ?"}, patch.fields)
+	s.Equal([]any{`{"a":"b"}`}, patch.args)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatchFromMergePatch_Conditions() {
+	patch, err := NewSQLPatchFromMergePatch[mergeUser]([]byte(`{"name": "jane"}`),
+		WithTable("users"),
+		WithWhereStr("id = ?", 1),
+		WithMergeConditions([]byte(`{"name": "john", "nick": null, "tags": ["a"]}`)),
+		WithMergeConditions([]byte(`{"address": {"city": "London"}}`)),
+	)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"name = ?", "nick IS NULL", "tags = ?", "addr_city = ?"}, patch.conditions)
+	s.Equal([]any{"john", `["a"]`, "London"}, patch.conditionArgs)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)\nAND name = ?\nAND nick IS NULL\nAND tags = ?\nAND addr_city = ?", sqlStr)
+	s.Equal([]any{"jane", 1, "john", `["a"]`, "London"}, args)
+
+	_, err = NewSQLPatchFromMergePatch[mergeUser]([]byte(`{"name": "jane"}`),
+		WithMergeConditions([]byte(`{"unknown": 1}`)),
+	)
+	s.Require().ErrorIs(err, ErrUnknownField)
+
+	_, err = NewSQLPatchFromMergePatch[mergeUser]([]byte(`{"name": "jane"}`),
+		WithMergeConditions([]byte(`["name"]`)),
+	)
+	s.Require().ErrorIs(err, ErrInvalidMergePatch)
+}
+
+func (s *mergePatchSuite) TestNewSQLPatch_Conditions() {
+	sqlStr, args, err := NewSQLPatch(&mergeUser{Name: ptr("jane")},
+		WithTable("users"),
+		WithWhereStr("id = ?", 1),
+		WithMergeConditions([]byte(`{"name": "john", "nick": null}`)),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)\nAND name = ?\nAND nick IS NULL", sqlStr)
+	s.Equal([]any{"jane", 1, "john"}, args)
+
+	_, _, err = NewSQLPatch(&mergeUser{Name: ptr("jane")},
+		WithTable("users"),
+		WithWhereStr("id = ?", 1),
+		WithMergeConditions([]byte(`{"unknown": 1}`)),
+	).GenerateSQL()
+	s.Require().ErrorIs(err, ErrUnknownField)
+}
+
+func (s *mergePatchSuite) TestNewDiffSQLPatch_Conditions() {
+	old := mergeUser{Name: ptr("john"), Email: "john@example.com"}
+	newObj := mergeUser{Name: ptr("jane")}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj,
+		WithTable("users"),
+		WithWhereStr("id = ?", 1),
+		WithMergeConditions([]byte(`{"name": "john"}`)),
+	)
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)\nAND name = ?", sqlStr)
+	s.Equal([]any{"jane", 1, "john"}, args)
+}
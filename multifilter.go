@@ -1,47 +1,281 @@
 package patcher
 
-import "strings"
+import (
+	"strings"
+	"unicode"
+)
 
 type Filter interface {
 	Joiner
 	Wherer
 }
 
+// MultiFilter combines several filters into a single Filter. The filters are appended with their WHERE type, use
+// AndGroup, OrGroup and Not to group conditions explicitly. When a MultiFilter is used within another WHERE clause,
+// its conditions are wrapped in parentheses.
 type MultiFilter interface {
 	Filter
 	Add(filter any)
 }
 
 type multiFilter struct {
-	joinSql   *strings.Builder
-	joinArgs  []any
-	whereSql  *strings.Builder
-	whereArgs []any
+	// joiners are the joins added to the filter, listed again when the filter is added to another JOIN clause
+	joiners []Joiner
+
+	// wherers are the WHERE conditions added to the filter. They are rendered when the filter is added to a WHERE
+	// clause, as the dialect of the patch determines how their SQL is read.
+	wherers []Wherer
 }
 
+// Join returns the JOIN clauses of the filter, reading their string literals as in the default dialect. Identical
+// joins are only returned once.
 func (m *multiFilter) Join() (sqlStr string, args []any) {
-	return m.joinSql.String(), m.joinArgs
+	builder := new(strings.Builder)
+	joins := new(joinSet)
+
+	for _, joiner := range m.joiners {
+		// Joins reusing an alias are reported when the SQL of the patch is generated
+		_ = appendJoin(joiner, builder, &args, joins, defaultSyntax)
+	}
+
+	return builder.String(), args
 }
 
+// Where returns the WHERE conditions of the filter, reading their string literals as in the default dialect
 func (m *multiFilter) Where() (sqlStr string, args []any) {
-	return m.whereSql.String(), m.whereArgs
+	// Placeholders that do not match the arguments are reported when the SQL of the patch is generated
+	sqlStr, args, _ = m.where(defaultSyntax)
+	return sqlStr, args
 }
 
+// where renders the WHERE conditions of the filter, each preceded by its WHERE type
+func (m *multiFilter) where(syntax sqlSyntax) (sqlStr string, args []any, err error) {
+	builder := new(strings.Builder)
+	for _, wherer := range m.wherers {
+		if err := appendWhere(wherer, builder, &args, syntax); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return builder.String(), args, nil
+}
+
+// Add adds the JOIN and WHERE clauses of the filter. A join identical to one already added is ignored, while a
+// different join reusing an alias results in ErrJoinAliasCollision when the SQL of the patch is generated.
 func (m *multiFilter) Add(filter any) {
 	if joiner, ok := filter.(Joiner); ok {
-		appendJoin(joiner, m.joinSql, &m.joinArgs)
+		m.joiners = append(m.joiners, joiner)
 	}
 
 	if wherer, ok := filter.(Wherer); ok {
-		appendWhere(wherer, m.whereSql, &m.whereArgs)
+		m.wherers = append(m.wherers, wherer)
 	}
 }
 
+// joins returns the joins added to the filter
+func (m *multiFilter) joins() ([]Joiner, error) {
+	return m.joiners, nil
+}
+
 func NewMultiFilter() MultiFilter {
 	return &multiFilter{
-		joinSql:   new(strings.Builder),
-		joinArgs:  nil,
-		whereSql:  new(strings.Builder),
-		whereArgs: nil,
+		joiners: nil,
+		wherers: nil,
+	}
+}
+
+// groupWhere returns the WHERE conditions of the filter without the leading "AND" or "OR", wrapped in parentheses.
+// This keeps the precedence of the conditions when the filter is used within another WHERE clause.
+func (m *multiFilter) groupWhere(syntax sqlSyntax) (sqlStr string, args []any, err error) {
+	sqlStr, args, err = m.where(syntax)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sqlStr = strings.TrimSpace(sqlStr)
+	for _, wt := range []WhereType{WhereTypeAnd, WhereTypeOr} {
+		if trimmed, ok := strings.CutPrefix(sqlStr, string(wt)+" "); ok {
+			sqlStr = trimmed
+			break
+		}
+	}
+
+	if sqlStr == "" {
+		return "", args, nil
+	}
+
+	return "(" + sqlStr + ")", args, nil
+}
+
+// whereGrouper is implemented by filters combining several WHERE conditions. The grouped conditions are used in place
+// of the Where method when the filter is appended to a WHERE clause, reporting any error found in the conditions.
+// The quoted parts of the conditions are read with the given syntax.
+type whereGrouper interface {
+	groupWhere(syntax sqlSyntax) (string, []any, error)
+}
+
+// groupOperator is the operator used to combine the filters of a WhereGroup
+type groupOperator string
+
+const (
+	groupOperatorAnd groupOperator = "AND"
+	groupOperatorOr  groupOperator = "OR"
+	groupOperatorNot groupOperator = "NOT"
+)
+
+// WhereGroup combines filters into a single condition wrapped in parentheses, allowing conditions such as
+// "a AND (b OR c)" to be expressed. A WhereGroup implements Filter, so it can be added to a MultiFilter, passed to
+// WithFilter or WithWhere, or nested in another group. The JOIN clauses of the filters in the group are kept.
+//
+// The WHERE types of the filters in a group are ignored, the filters are combined with the operator of the group.
+// The group itself is appended with "AND" unless Or is called.
+type WhereGroup struct {
+	operator  groupOperator
+	filters   []Wherer
+	whereType WhereType
+}
+
+// AndGroup creates a group matching when all the filters match. An empty group adds no condition.
+func AndGroup(filters ...Wherer) *WhereGroup {
+	return newWhereGroup(groupOperatorAnd, filters)
+}
+
+// OrGroup creates a group matching when any of the filters match. An empty group adds no condition.
+func OrGroup(filters ...Wherer) *WhereGroup {
+	return newWhereGroup(groupOperatorOr, filters)
+}
+
+// Not creates a group matching when the filter does not match
+func Not(filter Wherer) *WhereGroup {
+	return newWhereGroup(groupOperatorNot, []Wherer{filter})
+}
+
+func newWhereGroup(operator groupOperator, filters []Wherer) *WhereGroup {
+	return &WhereGroup{
+		operator:  operator,
+		filters:   filters,
+		whereType: WhereTypeAnd,
+	}
+}
+
+// Or returns a copy of the group that is appended to the WHERE clause with "OR"
+func (g *WhereGroup) Or() *WhereGroup {
+	grp := *g
+	grp.whereType = WhereTypeOr
+	return &grp
+}
+
+// WhereType returns the type used to append the group to the WHERE clause
+func (g *WhereGroup) WhereType() WhereType {
+	return g.whereType
+}
+
+// Where returns the condition of the group and its arguments, in the order of the filters. The string literals of the
+// conditions are read as in the default dialect.
+func (g *WhereGroup) Where() (sqlStr string, args []any) {
+	// Placeholders that do not match the arguments are reported when the SQL of the patch is generated
+	sqlStr, args, _ = g.groupWhere(defaultSyntax)
+	return sqlStr, args
+}
+
+// groupWhere returns the condition of the group and its arguments, or an error if the placeholders of a filter do not
+// match its arguments
+func (g *WhereGroup) groupWhere(syntax sqlSyntax) (sqlStr string, args []any, err error) {
+	conditions, args, err := groupConditions(g.filters, g.operator == groupOperatorNot, syntax)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// An empty group is skipped like an empty MultiFilter, so that it never satisfies the requirement for a WHERE
+	// clause while matching every row
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+
+	if g.operator == groupOperatorNot {
+		return "NOT " + conditions[0], args, nil
+	}
+
+	return "(" + strings.Join(conditions, " "+string(g.operator)+" ") + ")", args, nil
+}
+
+// Join returns the JOIN clauses of the filters in the group
+func (g *WhereGroup) Join() (sqlStr string, args []any) {
+	builder := new(strings.Builder)
+	args = make([]any, 0)
+
+	// Joins reusing an alias are reported when the SQL of the patch is generated
+	_ = appendJoin(g, builder, &args, new(joinSet), defaultSyntax)
+
+	return builder.String(), args
+}
+
+// joins returns the joins of the filters in the group
+func (g *WhereGroup) joins() ([]Joiner, error) {
+	joiners := make([]Joiner, 0)
+	for _, filter := range g.filters {
+		if joiner, ok := filter.(Joiner); ok {
+			joiners = append(joiners, joiner)
+		}
 	}
+	return joiners, nil
+}
+
+// groupConditions returns the conditions of the filters within a group and their arguments. Conditions are wrapped
+// in parentheses when the operator combining them could change their meaning, or always when wrapAll is set.
+func groupConditions(filters []Wherer, wrapAll bool, syntax sqlSyntax) (conditions []string, args []any, err error) {
+	conditions = make([]string, 0, len(filters))
+	args = make([]any, 0)
+
+	for _, filter := range filters {
+		if filter == nil {
+			continue
+		}
+
+		cond, condArgs, grouped, err := groupCondition(filter, syntax)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cond == "" {
+			continue
+		}
+
+		if !grouped && (wrapAll || hasLogicalOperator(cond)) {
+			cond = "(" + cond + ")"
+		}
+
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
+	}
+
+	return conditions, args, nil
+}
+
+// groupCondition returns the condition of a filter within a group, and whether the condition is already wrapped in
+// parentheses by a group. The $1, $2, $3 placeholders of the condition are converted to ? placeholders.
+func groupCondition(filter Wherer, syntax sqlSyntax) (sqlStr string, args []any, grouped bool, err error) {
+	if grouper, ok := filter.(whereGrouper); ok {
+		sqlStr, args, err = grouper.groupWhere(syntax)
+		return sqlStr, args, true, err
+	}
+
+	sqlStr, args = filter.Where()
+	sqlStr, args, err = normalizePlaceholders(strings.TrimSpace(sqlStr), args, syntax)
+
+	return sqlStr, args, false, err
+}
+
+// hasLogicalOperator determines whether the SQL contains an "AND" or "OR" keyword
+func hasLogicalOperator(sqlStr string) bool {
+	words := strings.FieldsFunc(sqlStr, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	for _, word := range words {
+		if strings.EqualFold(word, string(WhereTypeAnd)) || strings.EqualFold(word, string(WhereTypeOr)) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -19,11 +19,11 @@ func (s *multiFilterSuite) TestNewMultiFilter_Add_Single() {
 	s.NotNil(mf)
 
 	mw := NewMockWherer(s.T())
-	mw.On("Where").Return("where", []any{"arg1", "arg2"})
+	mw.On("Where").Return("where_val = ? and arg2_val = ?", []any{"arg1", "arg2"})
 	mf.Add(mw)
 
 	sql, args := mf.Where()
-	s.Equal("AND where\n", sql)
+	s.Equal("AND where_val = ? and arg2_val = ?\n", sql)
 	s.Equal([]any{"arg1", "arg2"}, args)
 }
 
@@ -32,15 +32,15 @@ func (s *multiFilterSuite) TestNewMultiFilter_Add_Multi() {
 	s.NotNil(mf)
 
 	mw := NewMockWherer(s.T())
-	mw.On("Where").Return("where", []any{"arg1", "arg2"})
+	mw.On("Where").Return("where_val = ? and arg2_val = ?", []any{"arg1", "arg2"})
 	mf.Add(mw)
 
 	mwTwo := NewMockWherer(s.T())
-	mwTwo.On("Where").Return("whereTwo", []any{"arg3", "arg4"})
+	mwTwo.On("Where").Return("arg3_val = ? and arg4_val = ?", []any{"arg3", "arg4"})
 	mf.Add(mwTwo)
 
 	sql, args := mf.Where()
-	s.Equal("AND where\nAND whereTwo\n", sql)
+	s.Equal("AND where_val = ? and arg2_val = ?\nAND arg3_val = ? and arg4_val = ?\n", sql)
 	s.Equal([]any{"arg1", "arg2", "arg3", "arg4"}, args)
 }
 
@@ -49,16 +49,16 @@ func (s *multiFilterSuite) TestNewMultiFilter_Add_WhereTyper() {
 	s.NotNil(mf)
 
 	mw := NewMockWherer(s.T())
-	mw.On("Where").Return("where", []any{"arg1", "arg2"})
+	mw.On("Where").Return("where_val = ? and arg2_val = ?", []any{"arg1", "arg2"})
 	mf.Add(mw)
 
 	mwt := NewMockWhereTyper(s.T())
-	mwt.On("Where").Return("whereTwo", []any{"arg3", "arg4"})
+	mwt.On("Where").Return("arg3_val = ? and arg4_val = ?", []any{"arg3", "arg4"})
 	mwt.On("WhereType").Return(WhereTypeOr)
 	mf.Add(mwt)
 
 	sql, args := mf.Where()
-	s.Equal("AND where\nOR whereTwo\n", sql)
+	s.Equal("AND where_val = ? and arg2_val = ?\nOR arg3_val = ? and arg4_val = ?\n", sql)
 	s.Equal([]any{"arg1", "arg2", "arg3", "arg4"}, args)
 }
 
@@ -67,11 +67,11 @@ func (s *multiFilterSuite) TestNewMultiFilter_Add_Joiner() {
 	s.NotNil(mf)
 
 	mj := NewMockJoiner(s.T())
-	mj.On("Join").Return("join", []any{"arg1", "arg2"})
+	mj.On("Join").Return("JOIN table2 ON table2.arg1 = ? and table2.arg2 = ?", []any{"arg1", "arg2"})
 	mf.Add(mj)
 
 	sql, args := mf.Join()
-	s.Equal("join\n", sql)
+	s.Equal("JOIN table2 ON table2.arg1 = ? and table2.arg2 = ?\n", sql)
 	s.Equal([]any{"arg1", "arg2"}, args)
 }
 
@@ -80,15 +80,15 @@ func (s *multiFilterSuite) TestNewMultiFilter_Add_MultiJoiner() {
 	s.NotNil(mf)
 
 	mj := NewMockJoiner(s.T())
-	mj.On("Join").Return("join", []any{"arg1", "arg2"})
+	mj.On("Join").Return("JOIN table2 ON table2.arg1 = ? and table2.arg2 = ?", []any{"arg1", "arg2"})
 	mf.Add(mj)
 
 	mjTwo := NewMockJoiner(s.T())
-	mjTwo.On("Join").Return("joinTwo", []any{"arg3", "arg4"})
+	mjTwo.On("Join").Return("JOIN table3 ON table3.arg3 = ? and table3.arg4 = ?", []any{"arg3", "arg4"})
 	mf.Add(mjTwo)
 
 	sql, args := mf.Join()
-	s.Equal("join\njoinTwo\n", sql)
+	s.Equal("JOIN table2 ON table2.arg1 = ? and table2.arg2 = ?\nJOIN table3 ON table3.arg3 = ? and table3.arg4 = ?\n", sql)
 	s.Equal([]any{"arg1", "arg2", "arg3", "arg4"}, args)
 }
 
@@ -97,18 +97,196 @@ func (s *multiFilterSuite) TestNewMultiFilter_Add_JoinerAndWherer() {
 	s.NotNil(mf)
 
 	mj := NewMockJoiner(s.T())
-	mj.On("Join").Return("join", []any{"arg1", "arg2"})
+	mj.On("Join").Return("JOIN table2 ON table2.arg1 = ? and table2.arg2 = ?", []any{"arg1", "arg2"})
 	mf.Add(mj)
 
 	mw := NewMockWherer(s.T())
-	mw.On("Where").Return("where", []any{"arg3", "arg4"})
+	mw.On("Where").Return("arg3_val = ? and arg4_val = ?", []any{"arg3", "arg4"})
 	mf.Add(mw)
 
 	sql, args := mf.Join()
-	s.Equal("join\n", sql)
+	s.Equal("JOIN table2 ON table2.arg1 = ? and table2.arg2 = ?\n", sql)
 	s.Equal([]any{"arg1", "arg2"}, args)
 
 	sql, args = mf.Where()
-	s.Equal("AND where\n", sql)
+	s.Equal("AND arg3_val = ? and arg4_val = ?\n", sql)
 	s.Equal([]any{"arg3", "arg4"}, args)
 }
+
+func (s *multiFilterSuite) TestWhereGroup_Where() {
+	a := &whereStringOption{where: "a = ?", args: []any{1}}
+	b := &whereStringOption{where: "b = ?", args: []any{2}}
+	c := &whereStringOption{where: "c = ? OR d = ?", args: []any{3, 4}}
+
+	tests := []struct {
+		name     string
+		group    *WhereGroup
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "And group",
+			group:    AndGroup(a, b),
+			wantSQL:  "(a = ? AND b = ?)",
+			wantArgs: []any{1, 2},
+		},
+		{
+			name:     "Or group",
+			group:    OrGroup(a, b),
+			wantSQL:  "(a = ? OR b = ?)",
+			wantArgs: []any{1, 2},
+		},
+		{
+			name:     "Not",
+			group:    Not(a),
+			wantSQL:  "NOT (a = ?)",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "Nested groups",
+			group:    AndGroup(a, OrGroup(b, Not(c))),
+			wantSQL:  "(a = ? AND (b = ? OR NOT (c = ? OR d = ?)))",
+			wantArgs: []any{1, 2, 3, 4},
+		},
+		{
+			name:     "Compound condition wrapped",
+			group:    AndGroup(c, a),
+			wantSQL:  "((c = ? OR d = ?) AND a = ?)",
+			wantArgs: []any{3, 4, 1},
+		},
+		{
+			name:     "Empty and group",
+			group:    AndGroup(),
+			wantSQL:  "",
+			wantArgs: []any{},
+		},
+		{
+			name:     "Empty or group",
+			group:    OrGroup(nil, &whereStringOption{}),
+			wantSQL:  "",
+			wantArgs: []any{},
+		},
+		{
+			name:     "Empty nested groups",
+			group:    AndGroup(a, OrGroup(AndGroup(), Not(nil))),
+			wantSQL:  "(a = ?)",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "Where types ignored",
+			group:    AndGroup(a, OrGroup(b).Or()),
+			wantSQL:  "(a = ? AND (b = ?))",
+			wantArgs: []any{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			sql, args := tt.group.Where()
+			s.Equal(tt.wantSQL, sql)
+			s.Equal(tt.wantArgs, args)
+		})
+	}
+}
+
+func (s *multiFilterSuite) TestWhereGroup_WhereType() {
+	group := AndGroup()
+	s.Equal(WhereTypeAnd, group.WhereType())
+
+	orGroup := group.Or()
+	s.Equal(WhereTypeOr, orGroup.WhereType())
+	s.Equal(WhereTypeAnd, group.WhereType())
+}
+
+func (s *multiFilterSuite) TestWhereGroup_Join() {
+	group := OrGroup(new(testFilter), &whereStringOption{where: "admin = ?", args: []any{true}})
+
+	sql, args := group.Join()
+	s.Equal("JOIN table2 ON table1.id = table2.id\n", sql)
+	s.Equal([]any{}, args)
+
+	sql, args = group.Where()
+	s.Equal("(age = ? OR admin = ?)", sql)
+	s.Equal([]any{18, true}, args)
+}
+
+func (s *multiFilterSuite) TestNewMultiFilter_Add_Groups() {
+	mf := NewMultiFilter()
+	mf.Add(&whereStringOption{where: "a = ?", args: []any{1}})
+	mf.Add(OrGroup(
+		&whereStringOption{where: "b = ?", args: []any{2}},
+		&whereStringOption{where: "c = ?", args: []any{3}},
+	))
+	mf.Add(Not(&whereStringOption{where: "d = ?", args: []any{4}}).Or())
+
+	sql, args := mf.Where()
+	s.Equal("AND a = ?\nAND (b = ? OR c = ?)\nOR NOT (d = ?)\n", sql)
+	s.Equal([]any{1, 2, 3, 4}, args)
+
+	sql, args = mf.Join()
+	s.Empty(sql)
+	s.Empty(args)
+}
+
+func (s *multiFilterSuite) TestNewMultiFilter_Nested() {
+	inner := NewMultiFilter()
+	inner.Add(&whereStringOption{where: "b = ?", args: []any{2}})
+	inner.Add(&whereStringOption{where: "c = ?", args: []any{3}})
+
+	mf := NewMultiFilter()
+	mf.Add(&whereStringOption{where: "a = ?", args: []any{1}})
+	mf.Add(OrGroup(inner, &whereStringOption{where: "d = ?", args: []any{4}}))
+
+	sql, args := mf.Where()
+	s.Equal("AND a = ?\nAND ((b = ?\nAND c = ?) OR d = ?)\n", sql)
+	s.Equal([]any{1, 2, 3, 4}, args)
+}
+
+func (s *multiFilterSuite) TestNewMultiFilter_WithFilter() {
+	mf := NewMultiFilter()
+	mf.Add(&joinStringOption{join: "JOIN orgs ON orgs.id = users.org_id AND orgs.region = ?", args: []any{"eu"}})
+	mf.Add(&whereStringOption{where: "orgs.name = ?", args: []any{"acme"}})
+	mf.Add(OrGroup(
+		&whereStringOption{where: "users.age > ?", args: []any{18}},
+		&whereStringOption{where: "users.admin = ?", args: []any{true}},
+	))
+
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(user{Name: "John"},
+		WithTable("users"),
+		WithFilter(mf),
+		WithWhereStr("users.deleted = ?", false),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\n"+
+		"JOIN orgs ON orgs.id = users.org_id AND orgs.region = ?\n"+
+		"SET name = ?\n"+
+		"WHERE (1=1)\n"+
+		"AND (\n"+
+		"(orgs.name = ?\nAND (users.age > ? OR users.admin = ?))\n"+
+		"AND users.deleted = ?\n"+
+		")", sqlStr)
+	s.Equal([]any{"eu", "John", "acme", 18, true, false}, args)
+}
+
+func (s *multiFilterSuite) TestNewMultiFilter_WithFilter_Empty() {
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(user{Name: "John"},
+		WithTable("users"),
+		WithFilter(NewMultiFilter()),
+		WithWhereStr("id = ?", 1),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"John", 1}, args)
+}
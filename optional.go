@@ -0,0 +1,129 @@
+package patcher
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// OptionalValue is implemented by every Optional, allowing the state of the value to be inspected without knowing
+// its type parameter.
+type OptionalValue interface {
+	// IsSet returns true if the value has been set, either to NULL or to a value
+	IsSet() bool
+
+	// IsNull returns true if the value has been explicitly set to NULL
+	IsNull() bool
+
+	// AnyValue returns the value, or nil if the value is unset or NULL
+	AnyValue() any
+}
+
+// Optional is a tri-state value that is either unset, explicitly set to NULL or set to a value.
+//
+// Unlike a pointer, an Optional can tell apart a field that was not provided from a field that was set to NULL. When
+// generating a patch, an unset Optional is skipped, a NULL Optional sets the column to NULL and a set Optional is
+// always written, even when it holds the zero value. When decoded from JSON, an absent key leaves the Optional unset
+// and a JSON null sets it to NULL.
+type Optional[T any] struct {
+	value T
+	set   bool
+	null  bool
+}
+
+// Some returns an Optional set to the given value
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{
+		value: value,
+		set:   true,
+	}
+}
+
+// Null returns an Optional explicitly set to NULL
+func Null[T any]() Optional[T] {
+	return Optional[T]{
+		set:  true,
+		null: true,
+	}
+}
+
+// IsSet returns true if the value has been set, either to NULL or to a value
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsNull returns true if the value has been explicitly set to NULL
+func (o Optional[T]) IsNull() bool {
+	return o.set && o.null
+}
+
+// IsZero returns true if the value is unset. This allows the `omitzero` json tag option to omit unset values.
+func (o Optional[T]) IsZero() bool {
+	return !o.set
+}
+
+// Get returns the value and whether it holds a value, i.e. it is set and not NULL
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set && !o.null
+}
+
+// AnyValue returns the value, or nil if the value is unset or NULL
+func (o Optional[T]) AnyValue() any {
+	if !o.set || o.null {
+		return nil
+	}
+
+	return o.value
+}
+
+// MarshalJSON encodes the value as JSON. Unset and NULL values are encoded as null.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set || o.null {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON decodes the value from JSON. A JSON null sets the value to NULL. This is not called for absent keys,
+// which leaves the value unset.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = Null[T]()
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*o = Some(value)
+	return nil
+}
+
+// Value implements the driver.Valuer interface. Unset and NULL values are written as NULL.
+func (o Optional[T]) Value() (driver.Value, error) {
+	if !o.set || o.null {
+		return nil, nil
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(o.value)
+}
+
+// Scan implements the sql.Scanner interface. A NULL column sets the value to NULL.
+func (o *Optional[T]) Scan(src any) error {
+	if src == nil {
+		*o = Null[T]()
+		return nil
+	}
+
+	var value sql.Null[T]
+	if err := value.Scan(src); err != nil {
+		return err
+	}
+
+	*o = Some(value.V)
+	return nil
+}
@@ -0,0 +1,174 @@
+package patcher
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type optionalSuite struct {
+	suite.Suite
+}
+
+func TestOptionalSuite(t *testing.T) {
+	suite.Run(t, new(optionalSuite))
+}
+
+type optionalUser struct {
+	ID    int              `db:"id" json:"id"`
+	Name  Optional[string] `db:"name" json:"name"`
+	Age   Optional[int]    `db:"age" json:"age"`
+	Email Optional[string] `db:"email" json:"email"`
+}
+
+func (s *optionalSuite) TestStates() {
+	var unset Optional[int]
+	s.False(unset.IsSet())
+	s.False(unset.IsNull())
+	s.Nil(unset.AnyValue())
+
+	null := Null[int]()
+	s.True(null.IsSet())
+	s.True(null.IsNull())
+	s.Nil(null.AnyValue())
+
+	zero := Some(0)
+	s.True(zero.IsSet())
+	s.False(zero.IsNull())
+	s.Equal(0, zero.AnyValue())
+
+	value, ok := zero.Get()
+	s.True(ok)
+	s.Equal(0, value)
+}
+
+func (s *optionalSuite) TestUnmarshalJSON() {
+	var user optionalUser
+	err := json.Unmarshal([]byte(`{"id": 1, "name": null, "age": 0}`), &user)
+	s.Require().NoError(err)
+
+	s.Equal(Null[string](), user.Name)
+	s.Equal(Some(0), user.Age)
+	s.False(user.Email.IsSet())
+}
+
+func (s *optionalSuite) TestUnmarshalJSON_Invalid() {
+	var user optionalUser
+	err := json.Unmarshal([]byte(`{"age": "old"}`), &user)
+	s.Require().Error(err)
+}
+
+func (s *optionalSuite) TestMarshalJSON() {
+	type omitted struct {
+		Name Optional[string] `json:"name,omitzero"`
+		Age  Optional[int]    `json:"age,omitzero"`
+		Nick Optional[string] `json:"nick,omitzero"`
+	}
+
+	encoded, err := json.Marshal(omitted{
+		Name: Null[string](),
+		Age:  Some(0),
+	})
+	s.Require().NoError(err)
+	s.JSONEq(`{"name": null, "age": 0}`, string(encoded))
+}
+
+func (s *optionalSuite) TestValue() {
+	value, err := Some(1).Value()
+	s.Require().NoError(err)
+	s.Equal(int64(1), value)
+
+	value, err = Null[int]().Value()
+	s.Require().NoError(err)
+	s.Nil(value)
+}
+
+func (s *optionalSuite) TestScan() {
+	var opt Optional[string]
+	s.Require().NoError(opt.Scan("test"))
+	s.Equal(Some("test"), opt)
+
+	s.Require().NoError(opt.Scan(nil))
+	s.Equal(Null[string](), opt)
+}
+
+func (s *optionalSuite) TestNewSQLPatch() {
+	patch := NewSQLPatch(&optionalUser{
+		ID:   1,
+		Name: Null[string](),
+		Age:  Some(0),
+	})
+
+	s.Equal([]string{"id = ?", "name = ?", "age = ?"}, patch.fields)
+	s.Equal([]any{1, nil, 0}, patch.args)
+}
+
+func (s *optionalSuite) TestNewSQLPatch_IncludeZeroValues() {
+	patch := NewSQLPatch(&optionalUser{
+		Age: Some(30),
+	}, WithIncludeZeroValues(true), WithIgnoredFields("ID"))
+
+	s.Equal([]string{"age = ?"}, patch.fields)
+	s.Equal([]any{30}, patch.args)
+}
+
+func (s *optionalSuite) TestNewSQLPatch_Pointer() {
+	type testObj struct {
+		Name Optional[*string] `db:"name"`
+	}
+
+	patch := NewSQLPatch(&testObj{Name: Some(ptr("test"))})
+
+	s.Equal([]string{"name = ?"}, patch.fields)
+	s.Equal([]any{"test"}, patch.args)
+}
+
+func (s *optionalSuite) TestNewSQLPatchFromMergePatch() {
+	patch, err := NewSQLPatchFromMergePatch[optionalUser]([]byte(`{"name": null, "age": 0}`))
+	s.Require().NoError(err)
+
+	s.Equal([]string{"name = ?", "age = ?"}, patch.fields)
+	s.Equal([]any{nil, 0}, patch.args)
+}
+
+func (s *optionalSuite) TestLoadDiff() {
+	old := optionalUser{
+		ID:    1,
+		Name:  Some("John"),
+		Age:   Some(30),
+		Email: Some("john@example.com"),
+	}
+	newObj := optionalUser{
+		Name: Null[string](),
+		Age:  Some(0),
+	}
+
+	err := LoadDiff(&old, &newObj)
+	s.Require().NoError(err)
+
+	s.Equal(1, old.ID)
+	s.Equal(Null[string](), old.Name)
+	s.Equal(Some(0), old.Age)
+	s.Equal(Some("john@example.com"), old.Email)
+}
+
+func (s *optionalSuite) TestNewDiffSQLPatch() {
+	old := optionalUser{
+		ID:    1,
+		Name:  Some("John"),
+		Age:   Some(30),
+		Email: Some("john@example.com"),
+	}
+	newObj := optionalUser{
+		Name:  Some("John"),
+		Age:   Some(0),
+		Email: Null[string](),
+	}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"age = ?", "email = ?"}, patch.fields)
+	s.Equal([]any{0, nil}, patch.args)
+}
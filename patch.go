@@ -1,23 +1,15 @@
 package patcher
 
 import (
-	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"slices"
 	"strings"
-)
-
-// SQLDialect represents the SQL dialect to use for parameter placeholders
-type SQLDialect int
+	"time"
 
-const (
-	// DialectMySQL uses ? for parameter placeholders (default)
-	DialectMySQL SQLDialect = iota
-	// DialectSQLite uses ? for parameter placeholders (same as MySQL)
-	DialectSQLite
-	// DialectPostgreSQL uses $1, $2, $3 for parameter placeholders
-	DialectPostgreSQL
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
 )
 
 var (
@@ -35,6 +27,10 @@ var (
 
 	// ErrNoWhere is returned when no where clause is set
 	ErrNoWhere = errors.New("no where clause set")
+
+	// ErrStaleObject is returned when a versioned patch does not affect any rows, meaning the row has been modified
+	// since the version was read
+	ErrStaleObject = errors.New("stale object: the row has been modified since it was read")
 )
 
 type IgnoreFieldsFunc func(field *reflect.StructField) bool
@@ -46,8 +42,8 @@ type SQLPatch struct {
 	// args is the arguments to use in the SQL statement
 	args []any
 
-	// db is the database connection to use
-	db *sql.DB
+	// db is the executor used to run the SQL statement
+	db Executor
 
 	// tagName is the tag name to look for in the struct. This is an override from the default tag "db"
 	tagName string
@@ -61,12 +57,39 @@ type SQLPatch struct {
 	// whereArgs is the arguments to use in the where clause
 	whereArgs []any
 
+	// filters are the WHERE conditions given with the options. They are added to the where clause once all the
+	// options are applied, as the dialect determines how their SQL is read.
+	filters []Wherer
+
+	// sets are the SET clause expressions given with the WithSet option
+	sets []setExpression
+
+	// exprFields is the number of SET clauses with a SQL expression as the value
+	exprFields int
+
+	// clock returns the current time used for the fields tagged with autoupdate
+	clock Clock
+
+	// timestampSource determines where the timestamps of the fields tagged with autoupdate are generated
+	timestampSource TimestampSource
+
+	// primaryKeys are the primary key fields of the resource, used to generate the where clause when no filter
+	// is given
+	primaryKeys []primaryKey
+
 	// joinSql is the join clause to use in the SQL statement
 	joinSql *strings.Builder
 
 	// joinArgs is the arguments to use in the join clause
 	joinArgs []any
 
+	// joins tracks the joins added to the join clause, so that identical joins are only added once
+	joins joinSet
+
+	// joiners are the joins given with the options. They are added to the join clause once all the options are
+	// applied, as the dialect determines how their SQL is read.
+	joiners []Joiner
+
 	// includeZeroValues determines whether zero values should be included in the patch
 	includeZeroValues bool
 
@@ -81,8 +104,57 @@ type SQLPatch struct {
 	// This func should return true is the field is to be ignored
 	ignoreFieldsFunc IgnoreFieldsFunc
 
+	// fieldMask is the list of field paths to include in the patch. When set, only the listed fields are included,
+	// regardless of whether they hold zero or nil values.
+	fieldMask []string
+
+	// maskPaths is the set of resolved field paths of the field mask
+	maskPaths map[string]struct{}
+
+	// unchanged is the set of field paths that are the same in the old and new resources of a diff patch
+	unchanged map[string]struct{}
+
 	// dialect is the SQL dialect to use for parameter placeholders
-	dialect SQLDialect
+	dialect Dialect
+
+	// quoteIdentifiers determines whether the table and column names should be quoted using the dialect's
+	// identifier quoting
+	quoteIdentifiers bool
+
+	// versionColumn is the column used for optimistic concurrency control. It is set when a field is tagged with
+	// the version option.
+	versionColumn string
+
+	// versionArg is the current version of the resource, used to guard the update in the where clause
+	versionArg any
+
+	// mergeConditions are the merge patch documents given with the WithMergeConditions option
+	mergeConditions [][]byte
+
+	// conditions are the conditions resolved from the merge conditions. They are added to the where clause after
+	// the filters, so they do not replace the where clause generated from the primary keys.
+	conditions []string
+
+	// conditionArgs is the arguments to use in the conditions
+	conditionArgs []any
+
+	// rejectPrimaryKeys determines whether a merge patch document setting a primary key is rejected, rather than the
+	// key being used to generate the where clause. It is set by the jsonpatch package through patchhook.
+	rejectPrimaryKeys bool
+
+	// returning is the list of columns to return from the update. This is only supported by dialects that
+	// implement the RETURNING clause.
+	returning []string
+
+	// limit is the maximum number of rows the update is allowed to change. A zero value means no limit is applied.
+	limit int
+
+	// genErr is the first error encountered while generating the patch. It is returned when the SQL is generated.
+	genErr error
+
+	// timeout is the maximum duration a single execution of the patch is allowed to take. A zero value means no
+	// timeout is applied on top of the context provided by the caller.
+	timeout time.Duration
 }
 
 // newPatchDefaults creates a new SQLPatch with default options.
@@ -102,12 +174,21 @@ func newPatchDefaults(opts ...PatchOpt) *SQLPatch {
 		includeNilValues:  false,
 		ignoreFields:      nil,
 		ignoreFieldsFunc:  nil,
+		dialect:           DialectMySQL,
 	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
 
+	for _, join := range p.joiners {
+		p.appendJoin(join)
+	}
+
+	for _, where := range p.filters {
+		p.appendWhere(where)
+	}
+
 	return p
 }
 
@@ -138,7 +219,7 @@ func (s *SQLPatch) validatePerformPatch() error {
 		return ErrNoTable
 	case len(s.fields) == 0:
 		return ErrNoFields
-	case len(s.args) == 0:
+	case len(s.args) == 0 && s.exprFields == 0:
 		return ErrNoArgs
 	case s.whereSql.String() == "":
 		return ErrNoWhere
@@ -154,7 +235,7 @@ func (s *SQLPatch) validateSQLGen() error {
 		return ErrNoTable
 	case len(s.fields) == 0:
 		return ErrNoFields
-	case len(s.args) == 0:
+	case len(s.args) == 0 && s.exprFields == 0:
 		return ErrNoArgs
 	case s.whereSql.String() == "":
 		return ErrNoWhere
@@ -164,67 +245,103 @@ func (s *SQLPatch) validateSQLGen() error {
 }
 
 // shouldIncludeNil determines whether the field should be included in the patch
-func (s *SQLPatch) shouldIncludeNil(tag string) bool {
-	if s.includeNilValues {
+func (s *SQLPatch) shouldIncludeNil(opts fieldmeta.TagOptions) bool {
+	if s.includeNilValues || s.fieldMask != nil {
 		return true
 	}
 
-	return s.shouldOmitEmpty(tag)
+	return s.shouldOmitEmpty(opts)
 }
 
 // shouldIncludeZero determines whether zero values should be included in the patch
-func (s *SQLPatch) shouldIncludeZero(tag string) bool {
-	if s.includeZeroValues {
+func (s *SQLPatch) shouldIncludeZero(opts fieldmeta.TagOptions) bool {
+	if s.includeZeroValues || s.fieldMask != nil {
 		return true
 	}
 
-	return s.shouldOmitEmpty(tag)
+	return s.shouldOmitEmpty(opts)
 }
 
 // shouldOmitEmpty determines whether the field should be omitted if it is empty
-func (s *SQLPatch) shouldOmitEmpty(tag string) bool {
-	if tag != "" {
-		tags := strings.Split(tag, TagOptSeparator)
-		if slices.Contains(tags, TagOptOmitempty) {
-			return true
-		}
-	}
-
-	return false
+func (s *SQLPatch) shouldOmitEmpty(opts fieldmeta.TagOptions) bool {
+	return opts.Has(fieldmeta.OptOmitempty)
 }
 
-func (s *SQLPatch) shouldSkipField(fType *reflect.StructField, fVal reflect.Value) bool {
-	if !fType.IsExported() || !IsValidType(fVal) || s.checkSkipField(fType) {
+func (s *SQLPatch) shouldSkipField(meta *fieldmeta.FieldMeta, fVal reflect.Value) bool {
+	if !meta.Field.IsExported() || (!meta.ValidType && !meta.Options.Has(fieldmeta.OptJSON)) || s.checkSkipField(meta) {
 		return true
 	}
 
-	patcherOptsTag := fType.Tag.Get(TagOptsName)
-	if fVal.Kind() == reflect.Ptr && (fVal.IsNil() && !s.shouldIncludeNil(patcherOptsTag)) {
+	if isNilable(fVal) && (fVal.IsNil() && !s.shouldIncludeNil(meta.Options)) {
 		return true
-	} else if fVal.Kind() != reflect.Ptr && (fVal.IsZero() && !s.shouldIncludeZero(patcherOptsTag)) {
+	} else if !isNilable(fVal) && (fVal.IsZero() && !s.shouldIncludeZero(meta.Options)) {
 		return true
 	}
 
 	return false
 }
 
-func (s *SQLPatch) checkSkipField(field *reflect.StructField) bool {
-	// The ignore fields tag takes precedence over the ignore fields list
-	if s.checkSkipTag(field) {
-		return true
+// fieldArg returns the SQL argument for the field value. Fields tagged with the json option are encoded as JSON and
+// driver.Valuer implementations, such as sql.NullString, are resolved to their driver value. Errors are recorded and
+// returned when the SQL is generated.
+func (s *SQLPatch) fieldArg(meta *fieldmeta.FieldMeta, fVal reflect.Value) any {
+	if meta.Optional {
+		opt, _ := fVal.Interface().(OptionalValue)
+		if opt.AnyValue() == nil {
+			return nil
+		}
+
+		fVal = reflect.ValueOf(opt.AnyValue())
+	}
+
+	if !meta.Options.Has(fieldmeta.OptJSON) {
+		if !meta.Valuer && !meta.Optional {
+			return getValue(fVal)
+		}
+
+		valuer, ok := asValuer(fVal)
+		if !ok {
+			return getValue(fVal)
+		}
+
+		value, err := valuer.Value()
+		if err != nil {
+			if s.genErr == nil {
+				s.genErr = fmt.Errorf("resolve value of field %s: %w", meta.Field.Name, err)
+			}
+			return nil
+		}
+
+		return value
+	}
+
+	encoded, err := json.Marshal(getValue(fVal))
+	if err != nil {
+		if s.genErr == nil {
+			s.genErr = fmt.Errorf("encode json field %s: %w", meta.Field.Name, err)
+		}
+		return nil
 	}
 
-	return s.ignoredFieldsCheck(field)
+	return string(encoded)
 }
 
-func (s *SQLPatch) checkSkipTag(field *reflect.StructField) bool {
-	val, ok := field.Tag.Lookup(TagOptsName)
-	if !ok {
-		return false
+// quote quotes the identifier for the dialect if identifier quoting is enabled
+func (s *SQLPatch) quote(identifier string) string {
+	if !s.quoteIdentifiers {
+		return identifier
+	}
+
+	return s.dialect.QuoteIdentifier(identifier)
+}
+
+func (s *SQLPatch) checkSkipField(meta *fieldmeta.FieldMeta) bool {
+	// The ignore fields tag takes precedence over the ignore fields list
+	if meta.Options.Has(fieldmeta.OptSkip) {
+		return true
 	}
 
-	tags := strings.Split(val, TagOptSeparator)
-	return slices.Contains(tags, TagOptSkip)
+	return s.ignoredFieldsCheck(&meta.Field)
 }
 
 func (s *SQLPatch) ignoredFieldsCheck(field *reflect.StructField) bool {
@@ -235,6 +352,26 @@ func (s *SQLPatch) checkIgnoreFunc(field *reflect.StructField) bool {
 	return s.ignoreFieldsFunc != nil && s.ignoreFieldsFunc(field)
 }
 
+// fieldPath returns the path of the field used to track unchanged and masked fields. The path is only built when
+// either is in use, avoiding the allocation otherwise.
+func (s *SQLPatch) fieldPath(path, name string) string {
+	if s.unchanged == nil && s.maskPaths == nil {
+		return ""
+	}
+
+	return path + name
+}
+
+// isUnchanged determines whether the field at the given path has been detected as unchanged in a diff patch
+func (s *SQLPatch) isUnchanged(path string) bool {
+	if s.unchanged == nil {
+		return false
+	}
+
+	_, ok := s.unchanged[path]
+	return ok
+}
+
 func (s *SQLPatch) checkIgnoredFields(field string) bool {
 	return len(s.ignoreFields) > 0 && slices.Contains(s.ignoreFields, field)
 }
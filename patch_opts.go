@@ -2,13 +2,24 @@ package patcher
 
 import (
 	"database/sql"
+	"time"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
 )
 
 const (
-	TagOptsName     = "patcher"
-	TagOptSeparator = ","
-	TagOptSkip      = "-"
-	TagOptOmitempty = "omitempty"
+	TagOptsName      = fieldmeta.TagOptsName
+	TagOptSeparator  = fieldmeta.TagOptSeparator
+	TagOptSkip       = fieldmeta.TagOptSkip
+	TagOptOmitempty  = fieldmeta.TagOptOmitempty
+	TagOptVersion    = fieldmeta.TagOptVersion
+	TagOptInline     = fieldmeta.TagOptInline
+	TagOptJSON       = fieldmeta.TagOptJSON
+	TagOptPrefix     = fieldmeta.TagOptPrefix
+	TagOptIncrement  = fieldmeta.TagOptIncrement
+	TagOptAutoCreate = fieldmeta.TagOptAutoCreate
+	TagOptAutoUpdate = fieldmeta.TagOptAutoUpdate
+	TagOptValueSep   = fieldmeta.TagOptValueSep
 )
 
 type PatchOpt func(*SQLPatch)
@@ -43,7 +54,7 @@ func WithFilter(filter any) PatchOpt {
 // WithWhere sets the where clause to use in the SQL statement
 func WithWhere(where Wherer) PatchOpt {
 	return func(s *SQLPatch) {
-		appendWhere(where, s.whereSql, &s.whereArgs)
+		s.filters = append(s.filters, where)
 	}
 }
 
@@ -54,17 +65,18 @@ func WithWhere(where Wherer) PatchOpt {
 // want to specify the WHERE type or do a more complex WHERE clause.
 func WithWhereStr(where string, args ...any) PatchOpt {
 	return func(s *SQLPatch) {
-		appendWhere(&whereStringOption{
+		s.filters = append(s.filters, &whereStringOption{
 			where: where,
 			args:  args,
-		}, s.whereSql, &s.whereArgs)
+		})
 	}
 }
 
-// WithJoin sets the join clause to use in the SQL statement
+// WithJoin sets the join clause to use in the SQL statement. A join identical to one already added is ignored, while
+// a different join reusing the alias of a JoinClause results in ErrJoinAliasCollision when the SQL is generated.
 func WithJoin(join Joiner) PatchOpt {
 	return func(s *SQLPatch) {
-		appendJoin(join, s.joinSql, &s.joinArgs)
+		s.joiners = append(s.joiners, join)
 	}
 }
 
@@ -75,20 +87,33 @@ func WithJoin(join Joiner) PatchOpt {
 // want to specify the JOIN type or do a more complex JOIN clause.
 func WithJoinStr(join string, args ...any) PatchOpt {
 	return func(s *SQLPatch) {
-		appendJoin(&joinStringOption{
+		s.joiners = append(s.joiners, &joinStringOption{
 			join: join,
 			args: args,
-		}, s.joinSql, &s.joinArgs)
+		})
 	}
 }
 
 // WithDB sets the database connection to use
 func WithDB(db *sql.DB) PatchOpt {
 	return func(s *SQLPatch) {
+		if db == nil {
+			// Avoid storing a typed nil in the Executor interface
+			s.db = nil
+			return
+		}
 		s.db = db
 	}
 }
 
+// WithExecutor sets the executor to use when performing the patch. This can be a *sql.DB, *sql.Tx, *sql.Conn or any
+// type implementing the Executor interface, such as an instrumented database wrapper.
+func WithExecutor(exec Executor) PatchOpt {
+	return func(s *SQLPatch) {
+		s.db = exec
+	}
+}
+
 // WithIncludeZeroValues sets whether zero values should be included in the patch.
 //
 // This is useful when you want to set a field to zero.
@@ -119,6 +144,59 @@ func WithIgnoredFields(fields ...string) PatchOpt {
 	}
 }
 
+// WithFieldMask sets the fields to include in the patch, such as the update mask sent with a gRPC style request.
+//
+// Only the listed fields are included and their zero and nil values are written. Each path is matched against the
+// field name, json tag or column name, and nested fields are addressed with dotted paths such as "address.city".
+// Only the fields of structs flattened into their columns can be addressed, other nested structs are stored as a
+// single column and masked as a whole. Paths that do not map onto any column result in ErrInvalidFieldMask.
+func WithFieldMask(paths ...string) PatchOpt {
+	return func(s *SQLPatch) {
+		s.fieldMask = paths
+	}
+}
+
+// WithSet sets the column to the value of the SQL expression, such as patcher.Expr("login_count + ?", 1). The SET
+// clause is added after the fields of the resource and takes precedence over a field mapped to the same column.
+func WithSet(column string, expr Expression) PatchOpt {
+	return func(s *SQLPatch) {
+		s.sets = append(s.sets, setExpression{
+			column: column,
+			expr:   expr,
+		})
+	}
+}
+
+// WithMergeConditions adds the conditions of a merge patch document to the where clause, so that the update only
+// applies when the columns still hold the given values. Each key present in the document is compared against its
+// column, with a JSON null compared using IS NULL. This is used to translate the "test" operations of a JSON Patch.
+//
+// The document is resolved against the type of the resource when the patch is created, with paths that do not map
+// onto any field resulting in an UnknownFieldsError. The conditions are added after the filters and the where clause
+// generated from the primary keys, and do not satisfy the requirement for a where clause. LoadDiff does not generate
+// SQL, so it ignores the conditions.
+func WithMergeConditions(body []byte) PatchOpt {
+	return func(s *SQLPatch) {
+		s.mergeConditions = append(s.mergeConditions, body)
+	}
+}
+
+// WithClock sets the clock used to generate the timestamps of fields tagged with autoupdate. Default is time.Now.
+func WithClock(clock Clock) PatchOpt {
+	return func(s *SQLPatch) {
+		s.clock = clock
+	}
+}
+
+// WithTimestampSource sets where the timestamps of fields tagged with autoupdate are generated. Default is
+// TimestampSourceClock, which binds the time from the clock as an argument. TimestampSourceDatabase sets the fields
+// to CURRENT_TIMESTAMP instead.
+func WithTimestampSource(source TimestampSource) PatchOpt {
+	return func(s *SQLPatch) {
+		s.timestampSource = source
+	}
+}
+
 // WithIgnoredFieldsFunc sets a function that determines whether a field should be ignored when patching.
 func WithIgnoredFieldsFunc(f IgnoreFieldsFunc) PatchOpt {
 	return func(s *SQLPatch) {
@@ -128,9 +206,67 @@ func WithIgnoredFieldsFunc(f IgnoreFieldsFunc) PatchOpt {
 
 // WithDialect sets the SQL dialect to use for parameter placeholders.
 // Default is DialectMySQL which uses ? placeholders.
-// Use DialectPostgreSQL for $1, $2, $3 placeholders.
+// Use DialectPostgreSQL for $1, $2, $3 placeholders, DialectSQLServer for @p1, @p2, @p3 placeholders and
+// DialectOracle for :1, :2, :3 placeholders.
 func WithDialect(dialect SQLDialect) PatchOpt {
 	return func(s *SQLPatch) {
 		s.dialect = dialect
 	}
 }
+
+// WithDialectImpl sets the SQL dialect to use for a database that is not covered by the built-in dialects, such as
+// CockroachDB or DuckDB. The dialect determines the parameter placeholders, the identifier quoting, how the string
+// literals of the filters are read, how joins, limits and returned columns are written and the maximum number of
+// parameters of the statement. A nil dialect uses the default DialectMySQL.
+func WithDialectImpl(dialect Dialect) PatchOpt {
+	return func(s *SQLPatch) {
+		if dialect == nil {
+			dialect = DialectMySQL
+		}
+		s.dialect = dialect
+	}
+}
+
+// WithQuotedIdentifiers sets whether the table and column names should be quoted using the identifier quoting of the
+// dialect set with WithDialect or WithDialectImpl. MySQL uses backticks, SQL Server uses brackets, PostgreSQL, SQLite
+// and Oracle use double quotes.
+//
+// This is useful when columns are named after reserved words such as "order", "group" or "user", or when the table
+// is schema-qualified. Note that the where and join clauses are not modified.
+func WithQuotedIdentifiers(quoteIdentifiers bool) PatchOpt {
+	return func(s *SQLPatch) {
+		s.quoteIdentifiers = quoteIdentifiers
+	}
+}
+
+// WithTimeout sets the maximum duration a single execution of the patch is allowed to take.
+//
+// The timeout is applied on top of the context passed to PerformPatchContext, so whichever deadline is reached first
+// cancels the statement. A zero or negative duration disables the timeout.
+func WithTimeout(timeout time.Duration) PatchOpt {
+	return func(s *SQLPatch) {
+		s.timeout = timeout
+	}
+}
+
+// WithReturning sets the columns to return from the update using a RETURNING clause.
+//
+// This is supported by DialectPostgreSQL and DialectSQLite, and by DialectSQLServer using an OUTPUT clause listing
+// the INSERTED columns. Generating the SQL for any other dialect returns ErrReturningUnsupported. Use
+// PerformPatchReturning to scan the returned row into a struct.
+func WithReturning(columns ...string) PatchOpt {
+	return func(s *SQLPatch) {
+		s.returning = columns
+	}
+}
+
+// WithLimit sets the maximum number of rows the update is allowed to change. MySQL appends a LIMIT clause, SQL Server
+// uses UPDATE TOP and Oracle limits the ROWNUM in the where clause.
+//
+// PostgreSQL and SQLite do not support limiting an update, and MySQL does not support it for updates with joins.
+// Generating the SQL in these cases returns ErrLimitUnsupported. A zero or negative limit disables the limit.
+func WithLimit(limit int) PatchOpt {
+	return func(s *SQLPatch) {
+		s.limit = limit
+	}
+}
@@ -0,0 +1,99 @@
+package patcher
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Patchable is implemented by resources with generated, reflection-free patch builders. The builders are generated
+// with the patchergen command, for example:
+//
+//	//go:generate go run github.com/jacobbrewer1/patcher/cmd/patchergen -type User
+//
+// NewSQLPatch uses the builders automatically when the patch options do not change which fields are included,
+// falling back to reflection otherwise.
+type Patchable interface {
+	// PatchFields returns the columns and arguments of the fields to set, following the default rules: zero and nil
+	// values are omitted unless the field is tagged with omitempty, and primary keys are excluded.
+	PatchFields() (columns []string, args []any)
+
+	// PatchKeys returns the columns and values of the primary key fields. A nil value marks a key holding its zero
+	// value.
+	PatchKeys() (columns []string, values []any)
+}
+
+// DiffLoader is implemented by resources with a generated, reflection-free LoadDiff. LoadDiff uses it automatically
+// when the patch options do not change which fields are loaded.
+type DiffLoader[T any] interface {
+	// LoadDiffFrom loads the non-zero fields of the new resource into the resource
+	LoadDiffFrom(newT *T)
+}
+
+// Insertable is implemented by resources with a generated, reflection-free insert builder. inserter.NewBatch uses it
+// automatically when the batch options do not change which fields are inserted.
+type Insertable interface {
+	// InsertFields returns the columns and arguments of the fields to insert, excluding primary keys
+	InsertFields() (columns []string, args []any)
+}
+
+// usesDefaultFields determines whether the fields of the patch are selected using the default rules, allowing the
+// generated builders of a resource to be used in place of reflection. Merge conditions are resolved against the
+// struct type, so they also require reflection.
+func (s *SQLPatch) usesDefaultFields() bool {
+	return s.tagName == DefaultDbTagName &&
+		!s.includeZeroValues &&
+		!s.includeNilValues &&
+		len(s.ignoreFields) == 0 &&
+		s.ignoreFieldsFunc == nil &&
+		s.fieldMask == nil &&
+		s.unchanged == nil &&
+		len(s.mergeConditions) == 0
+}
+
+// patchableGen generates the SQL patch from the generated builders of the resource
+func (s *SQLPatch) patchableGen(resource Patchable) {
+	columns, args := resource.PatchFields()
+	s.fields = make([]string, 0, len(columns))
+	s.args = make([]any, 0, len(args))
+
+	for i, column := range columns {
+		if s.hasSet(column) {
+			continue
+		}
+
+		s.fields = append(s.fields, s.quote(column)+" = ?")
+		s.args = append(s.args, s.patchableArg(column, args[i]))
+	}
+
+	keys, values := resource.PatchKeys()
+	for i, column := range keys {
+		s.primaryKeys = append(s.primaryKeys, primaryKey{
+			field:  column,
+			column: s.quote(column),
+			value:  s.patchableArg(column, values[i]),
+			zero:   values[i] == nil,
+		})
+	}
+
+	s.setGen()
+	s.primaryKeyWhere()
+}
+
+// patchableArg resolves the argument returned by the generated builders. driver.Valuer implementations are resolved
+// to their driver value, in the same way as fields read through reflection.
+func (s *SQLPatch) patchableArg(column string, arg any) any {
+	valuer, ok := arg.(driver.Valuer)
+	if !ok {
+		return arg
+	}
+
+	value, err := valuer.Value()
+	if err != nil {
+		if s.genErr == nil {
+			s.genErr = fmt.Errorf("resolve value of column %s: %w", column, err)
+		}
+		return nil
+	}
+
+	return value
+}
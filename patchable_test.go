@@ -0,0 +1,268 @@
+package patcher
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type patchableSuite struct {
+	suite.Suite
+}
+
+func TestPatchableSuite(t *testing.T) {
+	suite.Run(t, new(patchableSuite))
+}
+
+func (s *patchableSuite) TestNewSQLPatch_MatchesReflection() {
+	tests := []struct {
+		name     string
+		resource patchableUser
+		opts     []PatchOpt
+	}{
+		{
+			name: "All fields",
+			resource: patchableUser{
+				ID:       1,
+				Name:     "John",
+				Email:    ptr("john@example.com"),
+				Nickname: ptr("Johnny"),
+				Phone:    sql.NullString{String: "0123", Valid: true},
+				Password: "secret",
+				Address:  patchableAddress{Street: "1 High Street", City: "London"},
+			},
+		},
+		{
+			name: "Zero and nil fields",
+			resource: patchableUser{
+				ID:    2,
+				Phone: sql.NullString{String: "0123"},
+			},
+		},
+		{
+			name:     "Quoted identifiers",
+			resource: patchableUser{ID: 3, Name: "John"},
+			opts:     []PatchOpt{WithDialect(DialectPostgreSQL), WithQuotedIdentifiers(true)},
+		},
+		{
+			name:     "Set expression",
+			resource: patchableUser{ID: 4, Name: "John", Email: ptr("john@example.com")},
+			opts:     []PatchOpt{WithSet("name", Expr("UPPER(?)", "john"))},
+		},
+		{
+			name:     "Include zero values",
+			resource: patchableUser{ID: 5, Name: "John"},
+			opts:     []PatchOpt{WithIncludeZeroValues(true)},
+		},
+		{
+			name:     "Ignored fields",
+			resource: patchableUser{ID: 6, Name: "John", Email: ptr("john@example.com")},
+			opts:     []PatchOpt{WithIgnoredFields("Email")},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			opts := append([]PatchOpt{WithTable("users")}, tt.opts...)
+
+			sqlStr, args, err := NewSQLPatch(tt.resource, opts...).GenerateSQL()
+			s.Require().NoError(err)
+
+			expectedSQL, expectedArgs, err := NewSQLPatch(reflectedUser(tt.resource), opts...).GenerateSQL()
+			s.Require().NoError(err)
+
+			s.Require().Equal(expectedSQL, sqlStr)
+			s.Require().Equal(expectedArgs, args)
+		})
+	}
+}
+
+func (s *patchableSuite) TestNewSQLPatch_UsesBuilders() {
+	resource := &stubPatchable{}
+
+	patch := NewSQLPatch(resource, WithTable("stubs"))
+	s.Require().Equal(1, resource.calls)
+	s.Require().Equal([]string{"name = ?"}, patch.Fields())
+	s.Require().Equal([]any{"stub"}, patch.Args())
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+	s.Require().Equal("UPDATE stubs\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Require().Equal([]any{"stub", 1}, args)
+}
+
+func (s *patchableSuite) TestNewSQLPatch_FallsBackToReflection() {
+	resource := &stubPatchable{}
+
+	NewSQLPatch(resource, WithTable("stubs"), WithIncludeNilValues(true))
+	s.Require().Zero(resource.calls)
+}
+
+func (s *patchableSuite) TestNewSQLPatch_ZeroPrimaryKey() {
+	_, _, err := NewSQLPatch(patchableUser{Name: "John"}).GenerateSQL()
+	s.Require().ErrorIs(err, ErrZeroPrimaryKey)
+	s.Require().ErrorContains(err, "primary key field is zero: id")
+}
+
+func (s *patchableSuite) TestNewSQLPatch_ValuerError() {
+	_, _, err := NewSQLPatch(&stubPatchable{valueErr: errors.New("boom")}).GenerateSQL()
+	s.Require().EqualError(err, "generate patch: resolve value of column value: boom")
+}
+
+func (s *patchableSuite) TestLoadDiff_MatchesReflection() {
+	old := patchableUser{
+		ID:       1,
+		Name:     "John",
+		Email:    ptr("john@example.com"),
+		Nickname: ptr("Johnny"),
+		Phone:    sql.NullString{String: "0123", Valid: true},
+		Password: "secret",
+		Address:  patchableAddress{Street: "1 High Street", City: "London"},
+	}
+
+	newT := patchableUser{
+		Name:     "Jane",
+		Phone:    sql.NullString{String: "0456"},
+		Password: "changed",
+		Address:  patchableAddress{City: "Paris"},
+	}
+
+	generated := old
+	s.Require().NoError(LoadDiff(&generated, &newT))
+
+	reflected := reflectedUser(old)
+	reflectedNew := reflectedUser(newT)
+	s.Require().NoError(LoadDiff(&reflected, &reflectedNew))
+
+	s.Require().Equal(patchableUser(reflected), generated)
+	s.Require().Equal("Jane", generated.Name)
+	s.Require().Nil(generated.Nickname)
+	s.Require().Equal("secret", generated.Password)
+	s.Require().Equal(patchableAddress{Street: "1 High Street", City: "Paris"}, generated.Address)
+}
+
+func (s *patchableSuite) TestLoadDiff_InvalidType() {
+	newT := patchableUser{}
+	s.Require().ErrorIs(LoadDiff(nil, &newT), ErrInvalidType)
+}
+
+// stubPatchable records the calls to its builders
+type stubPatchable struct {
+	calls    int
+	valueErr error
+}
+
+func (p *stubPatchable) PatchFields() ([]string, []any) {
+	p.calls++
+	if p.valueErr != nil {
+		return []string{"value"}, []any{errValuer{err: p.valueErr}}
+	}
+
+	return []string{"name"}, []any{"stub"}
+}
+
+func (p *stubPatchable) PatchKeys() ([]string, []any) {
+	return []string{"id"}, []any{1}
+}
+
+// errValuer is a driver.Valuer returning an error
+type errValuer struct {
+	err error
+}
+
+func (v errValuer) Value() (driver.Value, error) {
+	return nil, v.err
+}
+
+type patchableAddress struct {
+	Street string `db:"street"`
+	City   string `db:"city"`
+}
+
+type patchableUser struct {
+	ID       int              `db:"id,pk"`
+	Name     string           `db:"name"`
+	Email    *string          `db:"email"`
+	Nickname *string          `db:"nickname" patcher:"omitempty"`
+	Phone    sql.NullString   `db:"phone"`
+	Password string           `db:"password" patcher:"-"`
+	Address  patchableAddress `patcher:"prefix=address_"`
+}
+
+// reflectedUser has the fields of patchableUser without the generated builders, so it is patched using reflection
+type reflectedUser patchableUser
+
+// The builders below are generated by patchergen for patchableUser.
+
+func (v patchableUser) PatchFields() ([]string, []any) {
+	columns := make([]string, 0, 6)
+	args := make([]any, 0, 6)
+	if v.Name != "" {
+		columns = append(columns, "name")
+		args = append(args, v.Name)
+	}
+	if v.Email != nil {
+		columns = append(columns, "email")
+		args = append(args, *v.Email)
+	}
+	columns = append(columns, "nickname")
+	if v.Nickname != nil {
+		args = append(args, *v.Nickname)
+	} else {
+		args = append(args, nil)
+	}
+	if v.Phone != (sql.NullString{}) {
+		if value, err := v.Phone.Value(); err != nil || value != nil {
+			columns = append(columns, "phone")
+			args = append(args, v.Phone)
+		}
+	}
+	if v.Address.Street != "" {
+		columns = append(columns, "address_street")
+		args = append(args, v.Address.Street)
+	}
+	if v.Address.City != "" {
+		columns = append(columns, "address_city")
+		args = append(args, v.Address.City)
+	}
+	return columns, args
+}
+
+func (v patchableUser) PatchKeys() ([]string, []any) {
+	columns := make([]string, 0, 1)
+	values := make([]any, 0, 1)
+	columns = append(columns, "id")
+	if v.ID != 0 {
+		values = append(values, v.ID)
+	} else {
+		values = append(values, nil)
+	}
+	return columns, values
+}
+
+func (o *patchableUser) LoadDiffFrom(n *patchableUser) {
+	if n.ID != 0 {
+		o.ID = n.ID
+	}
+	if n.Name != "" {
+		o.Name = n.Name
+	}
+	if n.Email != nil {
+		o.Email = n.Email
+	}
+	o.Nickname = n.Nickname
+	if n.Phone != (sql.NullString{}) {
+		if value, err := n.Phone.Value(); err != nil || value != nil {
+			o.Phone = n.Phone
+		}
+	}
+	if n.Address.Street != "" {
+		o.Address.Street = n.Address.Street
+	}
+	if n.Address.City != "" {
+		o.Address.City = n.Address.City
+	}
+}
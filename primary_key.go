@@ -0,0 +1,76 @@
+package patcher
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
+)
+
+// ErrZeroPrimaryKey is returned when the WHERE clause is generated from the primary key fields but a key field holds
+// its zero value
+var ErrZeroPrimaryKey = errors.New("primary key field is zero")
+
+// primaryKey is a primary key field of the resource used to generate the WHERE clause
+type primaryKey struct {
+	// field is the name of the struct field
+	field string
+
+	// column is the (quoted) column name
+	column string
+
+	// value is the value of the key
+	value any
+
+	// zero is true if the key holds its zero value
+	zero bool
+}
+
+// primaryKeyGen registers the primary key field. Primary keys are never part of the SET clause and are used to
+// generate the WHERE clause when no filter is given.
+func (s *SQLPatch) primaryKeyGen(meta *fieldmeta.FieldMeta, fVal reflect.Value, tag string) {
+	if !meta.Field.IsExported() {
+		return
+	}
+
+	key := primaryKey{
+		field:  meta.Field.Name,
+		column: s.quote(tag),
+		zero:   !fVal.IsValid() || fVal.IsZero() || (fVal.Kind() == reflect.Ptr && fVal.Elem().IsZero()),
+	}
+
+	if !key.zero {
+		key.value = s.fieldArg(meta, fVal)
+	}
+
+	s.primaryKeys = append(s.primaryKeys, key)
+}
+
+// primaryKeyWhere generates the WHERE clause from the primary keys when no filter has been given. The conditions added
+// with WithMergeConditions are kept apart from the filters, so they do not prevent the primary key WHERE clause.
+func (s *SQLPatch) primaryKeyWhere() {
+	if len(s.primaryKeys) == 0 || s.whereSql.String() != "" {
+		return
+	}
+
+	conditions := make([]string, 0, len(s.primaryKeys))
+	args := make([]any, 0, len(s.primaryKeys))
+	for _, key := range s.primaryKeys {
+		if key.zero {
+			if s.genErr == nil {
+				s.genErr = fmt.Errorf("%w: %s", ErrZeroPrimaryKey, key.field)
+			}
+			return
+		}
+
+		conditions = append(conditions, key.column+" = ?")
+		args = append(args, key.value)
+	}
+
+	s.appendWhere(&whereStringOption{
+		where: strings.Join(conditions, "\nAND "),
+		args:  args,
+	})
+}
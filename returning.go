@@ -0,0 +1,215 @@
+package patcher
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
+)
+
+var (
+	// ErrReturningUnsupported is returned when a RETURNING clause is requested for a dialect that does not support it
+	ErrReturningUnsupported = errors.New("returning is not supported by the SQL dialect")
+
+	// ErrNoQuerier is returned when the executor is not able to run queries that return rows
+	ErrNoQuerier = errors.New("executor does not support queries")
+)
+
+// Querier is implemented by executors that are able to run statements returning rows. It is satisfied by *sql.DB,
+// *sql.Tx and *sql.Conn and is required when performing a patch with a RETURNING clause.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// ReturningStyle is the way the columns returned from an UPDATE statement are written in a dialect
+type ReturningStyle int
+
+const (
+	// ReturningUnsupported is used by dialects that cannot return the updated rows
+	ReturningUnsupported ReturningStyle = iota
+
+	// ReturningClause appends a RETURNING clause to the statement, as in PostgreSQL and SQLite
+	ReturningClause
+
+	// ReturningOutput adds an OUTPUT clause listing the INSERTED columns after the SET clause, as in SQL Server
+	ReturningOutput
+)
+
+// ReturningStyle returns the way the columns returned from an UPDATE statement are written in the dialect
+func (d SQLDialect) ReturningStyle() ReturningStyle {
+	switch d {
+	case DialectPostgreSQL, DialectSQLite:
+		return ReturningClause
+	case DialectSQLServer:
+		return ReturningOutput
+	default:
+		return ReturningUnsupported
+	}
+}
+
+// PerformPatchReturning executes the SQL update statement with a RETURNING clause, or an OUTPUT clause on SQL Server,
+// and scans the returned row into dest, which must be a pointer to a struct. See PerformPatchReturningContext for
+// details.
+func (s *SQLPatch) PerformPatchReturning(dest any) error {
+	return s.PerformPatchReturningContext(context.Background(), dest)
+}
+
+// PerformPatchReturningContext executes the SQL update statement with a RETURNING clause using the provided context
+// and scans the returned row into dest, which must be a pointer to a struct.
+//
+// The returned columns are mapped onto the fields of dest using the same tag mapping as the patch. If no columns have
+// been set with WithReturning, every column mapped by dest is returned. If the update does not match any rows,
+// sql.ErrNoRows is returned, or ErrStaleObject if the patch is versioned.
+func (s *SQLPatch) PerformPatchReturningContext(ctx context.Context, dest any) error {
+	if !isPointerToStruct(dest) {
+		return ErrInvalidType
+	}
+
+	if err := s.validatePerformPatch(); err != nil {
+		return fmt.Errorf("validate perform patch: %w", err)
+	}
+
+	querier, ok := s.db.(Querier)
+	if !ok {
+		return ErrNoQuerier
+	}
+
+	returning := s.returning
+	if len(returning) == 0 {
+		returning = s.returningColumns(reflect.TypeOf(dest).Elem())
+	}
+
+	sqlStr, args, err := s.generateSQL(returning)
+	if err != nil {
+		return fmt.Errorf("generate SQL: %w", err)
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	rows, err := querier.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if s.versionColumn != "" {
+			return ErrStaleObject
+		}
+
+		return sql.ErrNoRows
+	}
+
+	if err := s.scanReturning(rows, dest); err != nil {
+		return fmt.Errorf("scan returning: %w", err)
+	}
+
+	return rows.Err()
+}
+
+// columnField maps a column onto the index path of the struct field it is stored in
+type columnField struct {
+	column string
+	index  []int
+}
+
+// columnFields lists the columns mapped by the exported fields of the given struct type, flattening embedded and
+// inlined structs in the same way as the patch.
+func (s *SQLPatch) columnFields(typeOf reflect.Type, prefix string, index []int) []columnField {
+	columns := make([]columnField, 0, typeOf.NumField())
+	fields := fieldmeta.TypeFields(typeOf, s.tagName)
+	for i := range fields {
+		meta := &fields[i]
+		if !meta.Field.IsExported() || meta.Options.Has(fieldmeta.OptSkip) {
+			continue
+		}
+
+		fieldIndex := append(slices.Clone(index), i)
+
+		if meta.Flatten {
+			nestedType := meta.Field.Type
+			if nestedType.Kind() == reflect.Ptr {
+				nestedType = nestedType.Elem()
+			}
+
+			columns = append(columns, s.columnFields(nestedType, prefix+meta.Prefix, fieldIndex)...)
+			continue
+		}
+
+		columns = append(columns, columnField{
+			column: prefix + meta.Column,
+			index:  fieldIndex,
+		})
+	}
+
+	return columns
+}
+
+// returningColumns lists the columns mapped by the given struct type
+func (s *SQLPatch) returningColumns(typeOf reflect.Type) []string {
+	fields := s.columnFields(typeOf, "", nil)
+
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.column)
+	}
+
+	return columns
+}
+
+// scanReturning scans the current row into the fields of dest, matching the returned columns against the tag
+// mapping of the struct. Columns that do not map onto a field are discarded.
+func (s *SQLPatch) scanReturning(rows *sql.Rows, dest any) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("columns: %w", err)
+	}
+
+	destElem := reflect.ValueOf(dest).Elem()
+	fields := s.columnFields(destElem.Type(), "", nil)
+
+	fieldIndex := make(map[string][]int, len(fields))
+	for _, field := range fields {
+		fieldIndex[field.column] = field.index
+	}
+
+	targets := make([]any, len(columns))
+	for i, column := range columns {
+		index, ok := fieldIndex[column]
+		if !ok {
+			targets[i] = new(any)
+			continue
+		}
+
+		targets[i] = fieldByIndexAlloc(destElem, index).Addr().Interface()
+	}
+
+	return rows.Scan(targets...)
+}
+
+// fieldByIndexAlloc returns the nested field at the index path, allocating any nil struct pointers along the way
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+
+	return v
+}
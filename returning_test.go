@@ -0,0 +1,194 @@
+package patcher
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeConnector is a minimal database/sql connector that records the executed query and returns a fixed set of rows.
+type fakeConnector struct {
+	columns []string
+	rows    [][]driver.Value
+
+	query string
+	args  []driver.Value
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return &fakeConn{c: c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct {
+	c *fakeConnector
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c.c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type fakeStmt struct {
+	c     *fakeConnector
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.query, s.c.args = s.query, args
+	return driver.RowsAffected(len(s.c.rows)), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.query, s.c.args = s.query, args
+	return &fakeRows{columns: s.c.columns, rows: s.c.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type returningSuite struct {
+	suite.Suite
+}
+
+func TestReturningSuite(t *testing.T) {
+	suite.Run(t, new(returningSuite))
+}
+
+func (s *returningSuite) TestGenerateSQL_Returning_PostgreSQL() {
+	type testObj struct {
+		Name *string `db:"name"`
+	}
+
+	sqlStr, args, err := GenerateSQL(testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithDialect(DialectPostgreSQL),
+		WithReturning("id", "updated_at"),
+	)
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test_table\nSET name = $1\nWHERE (1=1)\nAND (\nid = $2\n)\nRETURNING id, updated_at", sqlStr)
+	s.Equal([]any{"test", 1}, args)
+}
+
+func (s *returningSuite) TestGenerateSQL_Returning_MySQL() {
+	type testObj struct {
+		Name *string `db:"name"`
+	}
+
+	_, _, err := GenerateSQL(testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithReturning("id"),
+	)
+	s.Require().ErrorIs(err, ErrReturningUnsupported)
+}
+
+func (s *returningSuite) TestPerformPatchReturning() {
+	type testObj struct {
+		Name *string `db:"name"`
+	}
+
+	type result struct {
+		ID        int    `db:"id"`
+		Name      string `db:"name"`
+		UpdatedAt string `db:"updated_at"`
+	}
+
+	conn := &fakeConnector{
+		columns: []string{"id", "name", "updated_at"},
+		rows:    [][]driver.Value{{int64(1), "test", "2024-01-01"}},
+	}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+
+	dest := new(result)
+	err := NewSQLPatch(testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithDialect(DialectSQLite),
+		WithDB(db),
+	).PerformPatchReturning(dest)
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test_table\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)\nRETURNING id, name, updated_at", conn.query)
+	s.Equal(&result{ID: 1, Name: "test", UpdatedAt: "2024-01-01"}, dest)
+}
+
+func (s *returningSuite) TestPerformPatchReturning_LeavesPatchUnchanged() {
+	type testObj struct {
+		Name *string `db:"name"`
+	}
+
+	conn := &fakeConnector{
+		columns: []string{"name"},
+		rows:    [][]driver.Value{{"test"}},
+	}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+
+	patch := NewSQLPatch(testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithDialect(DialectSQLite),
+		WithDB(db),
+	)
+	s.Require().NoError(patch.PerformPatchReturning(new(testObj)))
+	s.Equal("UPDATE test_table\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)\nRETURNING name", conn.query)
+
+	sqlStr, _, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+	s.Equal("UPDATE test_table\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+}
+
+func (s *returningSuite) TestPerformPatchReturning_NoRows() {
+	type testObj struct {
+		Name    *string `db:"name"`
+		Version int     `db:"version" patcher:"version"`
+	}
+
+	conn := &fakeConnector{columns: []string{"name"}}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+
+	err := NewSQLPatch(testObj{Name: ptr("test"), Version: 1},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithDialect(DialectPostgreSQL),
+		WithDB(db),
+	).PerformPatchReturning(new(testObj))
+	s.Require().ErrorIs(err, ErrStaleObject)
+}
+
+func (s *returningSuite) TestPerformPatchReturning_NoQuerier() {
+	type testObj struct {
+		Name *string `db:"name"`
+	}
+
+	err := NewSQLPatch(testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithDialect(DialectPostgreSQL),
+		WithExecutor(NewMockExecutor(s.T())),
+	).PerformPatchReturning(new(testObj))
+	s.Require().ErrorIs(err, ErrNoQuerier)
+}
@@ -1,16 +1,19 @@
 package patcher
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
 )
 
 const (
 	DefaultDbTagName = "db"
-	DBTagPrimaryKey  = "pk"
+	DBTagPrimaryKey  = fieldmeta.DBTagPrimaryKey
 )
 
 var (
@@ -21,6 +24,9 @@ var (
 // NewSQLPatch creates a new SQLPatch instance with the given resource and options.
 // It initializes the SQLPatch with default settings and generates the SQL patch
 // for the provided resource by processing its fields and applying the necessary tags and options.
+//
+// Resources implementing Patchable, such as those with builders generated by patchergen, are processed without
+// reflection when the options do not change which fields are included.
 func NewSQLPatch(resource any, opts ...PatchOpt) *SQLPatch {
 	sqlPatch := newPatchDefaults(opts...)
 	sqlPatch.patchGen(resource)
@@ -35,6 +41,11 @@ func (s *SQLPatch) patchGen(resource any) {
 		s.table = getTableName(resource)
 	}
 
+	if patchable, ok := resource.(Patchable); ok && s.usesDefaultFields() {
+		s.patchableGen(patchable)
+		return
+	}
+
 	resource = dereferenceIfPointer(resource)
 	ensureStruct(resource)
 
@@ -45,30 +56,134 @@ func (s *SQLPatch) patchGen(resource any) {
 	s.fields = make([]string, 0, numField)
 	s.args = make([]any, 0, numField)
 
-	for i := range numField {
-		structField := typeOf.Field(i)
+	if err := s.resolveFieldMask(typeOf); err != nil && s.genErr == nil {
+		s.genErr = err
+		return
+	}
+
+	s.patchGenFields(valueOf, "", "")
+	s.timestampGen(typeOf, "")
+	s.setGen()
+	s.conditionsGen(typeOf)
+	s.primaryKeyWhere()
+}
+
+// patchGenFields generates the SET clause components for the fields of the given struct value.
+// Embedded structs and nested structs tagged with the inline or prefix options are flattened into their
+// columns, with the column prefix and field path accumulated through each level.
+func (s *SQLPatch) patchGenFields(valueOf reflect.Value, prefix, path string) {
+	fields := fieldmeta.TypeFields(valueOf.Type(), s.tagName)
+
+	for i := range fields {
+		meta := &fields[i]
 		value := valueOf.Field(i)
-		tag := getTag(&structField, s.tagName)
-		optsTag := structField.Tag.Get(TagOptsName)
+		fieldPath := s.fieldPath(path, meta.Field.Name)
+
+		if meta.Flatten {
+			if s.checkSkipField(meta) {
+				continue
+			}
+
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+
+			s.patchGenFields(value, prefix+meta.Prefix, s.fieldPath(fieldPath, "."))
+			continue
+		}
+
+		tag := prefix + meta.Column
 
-		if s.shouldSkipField(&structField, value) {
+		if meta.ColumnOptions.Has(fieldmeta.OptPrimaryKey) {
+			s.primaryKeyGen(meta, value, tag)
+			continue
+		}
+
+		if meta.Options.Has(fieldmeta.OptVersion) {
+			s.versionGen(meta, value, tag)
+			continue
+		}
+
+		if meta.Options.Has(fieldmeta.OptAutoCreate | fieldmeta.OptAutoUpdate) {
+			// Automatic timestamps are generated once all fields have been processed
+			continue
+		}
+
+		if s.hasSet(tag) || s.isUnchanged(fieldPath) || s.isMaskedOut(fieldPath) || s.shouldSkipField(meta, value) {
+			continue
+		}
+
+		if meta.Expression {
+			if expr, _ := value.Interface().(Expression); expr.sql != "" {
+				s.exprGen(tag, expr)
+			}
+			continue
+		}
+
+		// An Optional is written whenever it is set, regardless of whether it holds NULL or the zero value
+		if meta.Optional {
+			if opt, _ := value.Interface().(OptionalValue); opt.IsSet() {
+				s.fieldGen(meta, tag, s.fieldArg(meta, value))
+			}
 			continue
 		}
 
 		var arg any = nil
-		if value.Kind() == reflect.Ptr && value.IsNil() {
-			if !s.shouldIncludeNil(optsTag) {
+		if isNilable(value) && value.IsNil() {
+			if !s.shouldIncludeNil(meta.Options) {
 				continue
 			}
 		} else {
-			arg = getValue(value)
+			arg = s.fieldArg(meta, value)
+
+			// A driver.Valuer resolving to NULL, such as sql.NullString with Valid set to false, is only
+			// written when nil values are requested
+			if arg == nil && !s.shouldIncludeNil(meta.Options) {
+				continue
+			}
 		}
 
-		s.fields = append(s.fields, tag+" = ?")
-		s.args = append(s.args, arg)
+		s.fieldGen(meta, tag, arg)
 	}
 }
 
+// fieldGen appends the SET clause for the column with the given argument. Fields tagged with the increment option are
+// incremented by the argument rather than set to it.
+func (s *SQLPatch) fieldGen(meta *fieldmeta.FieldMeta, tag string, arg any) {
+	switch {
+	case meta.Options.Has(fieldmeta.OptIncrement):
+		column := s.quote(tag)
+		s.fields = append(s.fields, column+" = "+column+" + ?")
+	case !s.quoteIdentifiers && tag == meta.Column:
+		// The SET clause of the plain column is cached with the field metadata
+		s.fields = append(s.fields, meta.SetClause)
+	default:
+		s.fields = append(s.fields, s.quote(tag)+" = ?")
+	}
+	s.args = append(s.args, arg)
+}
+
+// versionGen registers the version field for optimistic concurrency control. The version column is always
+// incremented in the SET clause and its current value is used to guard the update in the WHERE clause.
+func (s *SQLPatch) versionGen(meta *fieldmeta.FieldMeta, fVal reflect.Value, tag string) {
+	if !meta.Field.IsExported() || s.checkSkipField(meta) {
+		return
+	}
+
+	if fVal.Kind() == reflect.Ptr && fVal.IsNil() {
+		// No current version is known, so the update cannot be guarded
+		return
+	}
+
+	column := s.quote(tag)
+	s.versionColumn = column
+	s.versionArg = getValue(fVal)
+	s.fields = append(s.fields, column+" = "+column+" + 1")
+}
+
 // GenerateSQL generates the SQL update statement and its arguments for the given resource.
 // It creates a new SQLPatch instance with the provided options, processes the resource's fields,
 // and constructs the SQL update statement along with the necessary arguments.
@@ -81,64 +196,199 @@ func GenerateSQL(resource any, opts ...PatchOpt) (sqlStr string, args []any, err
 // with the table name, join clauses, set clauses, and where clauses,
 // and returns the final SQL string along with the arguments.
 func (s *SQLPatch) GenerateSQL() (sqlStr string, args []any, err error) {
+	return s.generateSQL(s.returning)
+}
+
+// generateSQL constructs the SQL update statement and its arguments, returning the given columns from the update
+func (s *SQLPatch) generateSQL(returning []string) (sqlStr string, args []any, err error) {
+	if s.genErr != nil {
+		return "", nil, fmt.Errorf("generate patch: %w", s.genErr)
+	}
+
 	if err := s.validateSQLGen(); err != nil {
 		return "", nil, fmt.Errorf("validate SQL generation: %w", err)
 	}
 
-	sqlBuilder := new(strings.Builder)
+	joinSQL := s.joinSql.String()
+	joinStyle := s.dialect.UpdateJoinStyle()
+
+	// Only MySQL supports joins directly in an UPDATE statement, the other dialects join the tables in a FROM
+	// clause instead
+	var from *fromClause
+	if joinSQL != "" {
+		switch joinStyle {
+		case UpdateJoinFrom:
+			from, err = newFromClause(s.table, s.joins.entries, dialectSyntax(s.dialect))
+			if err != nil {
+				return "", nil, fmt.Errorf("generate join: %w", err)
+			}
+		case UpdateJoinUnsupported:
+			return "", nil, fmt.Errorf("generate join: %w: joins cannot be used in an UPDATE statement", ErrUnsupportedJoin)
+		}
+	}
+
+	limit, err := s.limitClause(joinSQL != "")
+	if err != nil {
+		return "", nil, err
+	}
+
+	returningStyle := s.dialect.ReturningStyle()
+	if len(returning) > 0 && returningStyle == ReturningUnsupported {
+		return "", nil, ErrReturningUnsupported
+	}
+
+	// If the where clause starts with "AND" or "OR", we need to remove it
+	where := s.whereSql.String()
+	if strings.HasPrefix(where, string(WhereTypeAnd)) || strings.HasPrefix(where, string(WhereTypeOr)) {
+		where = strings.TrimPrefix(where, string(WhereTypeAnd))
+		where = strings.TrimPrefix(where, string(WhereTypeOr))
+	}
+	where = strings.TrimSpace(where)
+
+	size := len(s.table) + len(joinSQL) + len(where) + len(s.versionColumn) + 64
+	for _, field := range s.fields {
+		size += len(field) + 2
+	}
+
+	sqlBuilder := newPlaceholderWriter(s.dialect, size)
 	sqlBuilder.WriteString("UPDATE ")
-	sqlBuilder.WriteString(s.table)
+	if limit.top != "" {
+		sqlBuilder.WriteString(limit.top)
+	}
+	sqlBuilder.WriteString(s.quote(s.table))
 	sqlBuilder.WriteString("\n")
-
-	if s.joinSql.String() != "" {
-		sqlBuilder.WriteString(s.joinSql.String())
+	if joinStyle == UpdateJoinInline {
+		sqlBuilder.writeSQL(joinSQL)
 	}
 
 	sqlBuilder.WriteString("SET ")
-	sqlBuilder.WriteString(strings.Join(s.fields, ", "))
+	for i, field := range s.fields {
+		if i > 0 {
+			sqlBuilder.WriteString(", ")
+		}
+		sqlBuilder.writeSQL(field)
+	}
 	sqlBuilder.WriteString("\n")
 
+	if len(returning) > 0 && returningStyle == ReturningOutput {
+		sqlBuilder.WriteString("OUTPUT ")
+		for i, column := range returning {
+			if i > 0 {
+				sqlBuilder.WriteString(", ")
+			}
+			sqlBuilder.WriteString("INSERTED.")
+			sqlBuilder.WriteString(s.quote(column))
+		}
+		sqlBuilder.WriteString("\n")
+	}
+
+	if from != nil {
+		sqlBuilder.WriteString("FROM ")
+		sqlBuilder.writeSQL(from.from)
+		sqlBuilder.WriteString("\n")
+	} else if joinSQL != "" && joinStyle == UpdateJoinFromTarget {
+		sqlBuilder.WriteString("FROM ")
+		sqlBuilder.WriteString(s.quote(s.table))
+		sqlBuilder.WriteString("\n")
+		sqlBuilder.writeSQL(joinSQL)
+	}
+
 	sqlBuilder.WriteString("WHERE (1=1)\n")
+	if from != nil {
+		for _, cond := range from.conditions {
+			sqlBuilder.WriteString("AND (")
+			sqlBuilder.writeSQL(cond)
+			sqlBuilder.WriteString(")\n")
+		}
+	}
 	sqlBuilder.WriteString("AND (\n")
+	sqlBuilder.writeSQL(where)
+	sqlBuilder.WriteString("\n)")
 
-	// If the where clause starts with "AND" or "OR", we need to remove it
-	where := s.whereSql.String()
-	if strings.HasPrefix(where, string(WhereTypeAnd)) || strings.HasPrefix(where, string(WhereTypeOr)) {
-		where = strings.TrimPrefix(where, string(WhereTypeAnd))
-		where = strings.TrimPrefix(where, string(WhereTypeOr))
-		where = strings.TrimSpace(where)
+	for _, cond := range s.conditions {
+		sqlBuilder.WriteString("\nAND ")
+		sqlBuilder.writeSQL(cond)
 	}
 
-	sqlBuilder.WriteString(strings.TrimSpace(where) + "\n")
-	sqlBuilder.WriteString(")")
+	if s.versionColumn != "" {
+		sqlBuilder.WriteString("\nAND ")
+		sqlBuilder.WriteString(s.versionColumn)
+		sqlBuilder.writeSQL(" = ?")
+	}
+
+	if limit.suffix != "" {
+		sqlBuilder.WriteString("\n")
+		sqlBuilder.WriteString(limit.suffix)
+	}
 
-	sqlArgs := s.joinArgs
-	sqlArgs = append(sqlArgs, s.args...)
+	if len(returning) > 0 && returningStyle == ReturningClause {
+		sqlBuilder.WriteString("\nRETURNING ")
+		for i, column := range returning {
+			if i > 0 {
+				sqlBuilder.WriteString(", ")
+			}
+			sqlBuilder.WriteString(s.quote(column))
+		}
+	}
+
+	// The arguments follow the order of their placeholders in the statement
+	sqlArgs := make([]any, 0, len(s.joinArgs)+len(s.args)+len(s.whereArgs)+len(s.conditionArgs)+1)
+	switch {
+	case from != nil:
+		sqlArgs = append(sqlArgs, s.args...)
+		sqlArgs = append(sqlArgs, from.fromArgs...)
+		sqlArgs = append(sqlArgs, from.condArgs...)
+	case joinStyle == UpdateJoinFromTarget:
+		sqlArgs = append(sqlArgs, s.args...)
+		sqlArgs = append(sqlArgs, s.joinArgs...)
+	default:
+		sqlArgs = append(sqlArgs, s.joinArgs...)
+		sqlArgs = append(sqlArgs, s.args...)
+	}
 	sqlArgs = append(sqlArgs, s.whereArgs...)
+	sqlArgs = append(sqlArgs, s.conditionArgs...)
+	if s.versionColumn != "" {
+		sqlArgs = append(sqlArgs, s.versionArg)
+	}
 
-	// Convert parameter placeholders based on dialect
-	finalSQL := s.convertParameterPlaceholders(sqlBuilder.String())
+	if err := checkBindParameters(s.dialect, len(sqlArgs)); err != nil {
+		return "", nil, err
+	}
 
-	return finalSQL, sqlArgs, nil
+	return sqlBuilder.String(), sqlArgs, nil
 }
 
 // PerformPatch executes the SQL update statement for the given resource.
 // It creates a new SQLPatch instance with the provided options, generates the SQL update statement,
 // and executes it using the database connection.
 func PerformPatch(resource any, opts ...PatchOpt) (sql.Result, error) {
-	return NewSQLPatch(resource, opts...).PerformPatch()
+	return PerformPatchContext(context.Background(), resource, opts...)
+}
+
+// PerformPatchContext executes the SQL update statement for the given resource using the provided context.
+// It behaves like PerformPatch, but the context is passed through to the database driver so that
+// deadlines and cancellations are honoured.
+func PerformPatchContext(ctx context.Context, resource any, opts ...PatchOpt) (sql.Result, error) {
+	return NewSQLPatch(resource, opts...).PerformPatchContext(ctx)
 }
 
 // PerformDiffPatch executes the SQL update statement for the differences between the old and new resources.
 // It creates a new SQLPatch instance by comparing the old and new resources, generates the SQL update statement,
 // and executes it using the database connection.
 func PerformDiffPatch[T any](old, newT *T, opts ...PatchOpt) (sql.Result, error) {
+	return PerformDiffPatchContext(context.Background(), old, newT, opts...)
+}
+
+// PerformDiffPatchContext executes the SQL update statement for the differences between the old and new resources
+// using the provided context. It behaves like PerformDiffPatch, but the context is passed through to the database
+// driver so that deadlines and cancellations are honoured.
+func PerformDiffPatchContext[T any](ctx context.Context, old, newT *T, opts ...PatchOpt) (sql.Result, error) {
 	sqlPatch, err := NewDiffSQLPatch(old, newT, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("new diff sql patch: %w", err)
 	}
 
-	return sqlPatch.PerformPatch()
+	return sqlPatch.PerformPatchContext(ctx)
 }
 
 // PerformPatch executes the SQL update statement for the current SQLPatch instance.
@@ -146,6 +396,12 @@ func PerformDiffPatch[T any](old, newT *T, opts ...PatchOpt) (sql.Result, error)
 // and executes the statement using the database connection.
 // It returns the result of the SQL execution or an error if the process fails.
 func (s *SQLPatch) PerformPatch() (sql.Result, error) {
+	return s.PerformPatchContext(context.Background())
+}
+
+// PerformPatchContext executes the SQL update statement for the current SQLPatch instance using the provided context.
+// If a timeout has been configured with WithTimeout, it is applied on top of the given context.
+func (s *SQLPatch) PerformPatchContext(ctx context.Context) (sql.Result, error) {
 	if err := s.validatePerformPatch(); err != nil {
 		return nil, fmt.Errorf("validate perform patch: %w", err)
 	}
@@ -155,7 +411,31 @@ func (s *SQLPatch) PerformPatch() (sql.Result, error) {
 		return nil, fmt.Errorf("generate SQL: %w", err)
 	}
 
-	return s.db.Exec(sqlStr, args...)
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	res, err := s.db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.versionColumn == "" {
+		return res, nil
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		return nil, ErrStaleObject
+	}
+
+	return res, nil
 }
 
 // NewDiffSQLPatch creates a new SQLPatch instance by comparing the old and new resources.
@@ -166,11 +446,9 @@ func NewDiffSQLPatch[T any](old, newT *T, opts ...PatchOpt) (*SQLPatch, error) {
 		return nil, ErrInvalidType
 	}
 
-	// Take a copy of the old object
+	// Take a deep copy of the old object so that changes made to embedded struct pointers are detected
 	oldCopy := reflect.New(reflect.TypeOf(old).Elem()).Interface()
-
-	// copy the old object into the copy
-	reflect.ValueOf(oldCopy).Elem().Set(reflect.ValueOf(old).Elem())
+	reflect.ValueOf(oldCopy).Elem().Set(cloneStruct(reflect.ValueOf(old).Elem()))
 
 	patch := newPatchDefaults(opts...)
 	if err := patch.loadDiff(old, newT); err != nil {
@@ -182,57 +460,52 @@ func NewDiffSQLPatch[T any](old, newT *T, opts ...PatchOpt) (*SQLPatch, error) {
 		return nil, ErrNoChanges
 	}
 
-	oldElem := reflect.ValueOf(old).Elem()
-	oldCopyElem := reflect.ValueOf(oldCopy).Elem()
+	// For each field in the old object, compare it against the copy and mark the fields that are the same to be
+	// ignored in the patch.
+	patch.unchanged = make(map[string]struct{})
+	patch.diffUnchanged(reflect.ValueOf(old).Elem(), reflect.ValueOf(oldCopy).Elem(), "")
 
-	// For each field in the old object, compare it against the copy and if the fields are the same, set them to zero or nil.
-	for i := range reflect.ValueOf(old).Elem().NumField() {
-		oldField := oldElem.Field(i)
-		copyField := oldCopyElem.Field(i)
+	patch.patchGen(old)
+	return patch, nil
+}
 
-		patcherOptsTag := oldElem.Type().Field(i).Tag.Get(TagOptsName)
+// diffUnchanged records the path of every field that is the same in the old and copied struct values. Flattened
+// structs are compared field by field so that only the changed columns are included in the patch.
+func (s *SQLPatch) diffUnchanged(oldElem, copyElem reflect.Value, path string) {
+	fields := fieldmeta.TypeFields(oldElem.Type(), s.tagName)
 
-		if oldField.Kind() == reflect.Ptr && (oldField.IsNil() && copyField.IsNil() && !patch.shouldIncludeNil(patcherOptsTag)) {
-			continue
-		} else if oldField.Kind() != reflect.Ptr && (oldField.IsZero() && copyField.IsZero() && !patch.shouldIncludeZero(patcherOptsTag)) {
-			continue
-		}
+	for i := range fields {
+		meta := &fields[i]
+		oldField := oldElem.Field(i)
+		copyField := copyElem.Field(i)
+		fieldPath := path + meta.Field.Name
 
-		if !reflect.DeepEqual(oldField.Interface(), copyField.Interface()) {
+		if !meta.Field.IsExported() || meta.Options.Has(fieldmeta.OptVersion) {
+			// The version field is always part of the patch to guard the update
 			continue
 		}
 
-		// Field is the same, set it to zero or nil. Add it to be ignored in the patch
-		if patch.ignoreFields == nil {
-			patch.ignoreFields = make([]string, 0)
+		if meta.Flatten {
+			if oldField.Kind() != reflect.Ptr {
+				s.diffUnchanged(oldField, copyField, fieldPath+".")
+				continue
+			} else if !oldField.IsNil() && !copyField.IsNil() {
+				s.diffUnchanged(oldField.Elem(), copyField.Elem(), fieldPath+".")
+				continue
+			}
 		}
-		patch.ignoreFields = append(patch.ignoreFields, oldElem.Type().Field(i).Name)
-		continue
-	}
 
-	patch.patchGen(old)
-	return patch, nil
-}
-
-// convertParameterPlaceholders converts SQL parameter placeholders based on the dialect
-func (s *SQLPatch) convertParameterPlaceholders(sqlStr string) string {
-	if s.dialect == DialectPostgreSQL {
-		// Convert ? placeholders to $1, $2, $3, etc.
-		placeholderIndex := 1
-		result := strings.Builder{}
+		if oldField.Kind() == reflect.Ptr && (oldField.IsNil() && copyField.IsNil() && !s.shouldIncludeNil(meta.Options)) {
+			continue
+		} else if oldField.Kind() != reflect.Ptr && (oldField.IsZero() && copyField.IsZero() && !s.shouldIncludeZero(meta.Options)) {
+			continue
+		}
 
-		for _, char := range sqlStr {
-			if char == '?' {
-				result.WriteString(fmt.Sprintf("$%d", placeholderIndex))
-				placeholderIndex++
-			} else {
-				result.WriteRune(char)
-			}
+		if !valuesEqual(oldField, copyField) {
+			continue
 		}
 
-		return result.String()
+		// Field is the same, add it to be ignored in the patch
+		s.unchanged[fieldPath] = struct{}{}
 	}
-
-	// For MySQL and SQLite, return unchanged (they use ?)
-	return sqlStr
 }
@@ -1,10 +1,13 @@
 package patcher
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -112,7 +115,7 @@ func (s *newSQLPatchSuite) TestNewSQLPatch_Success_Filter_JoinerAndWhere() {
 
 	mf := NewMockFilter(s.T())
 	mf.On("Join").Return("JOIN table2 ON table1.id = table2.id and arg2_val = ?", []any{"arg1"})
-	mf.On("Where").Return("where", []any{"arg1", "arg2"})
+	mf.On("Where").Return("test_where = ? and arg2_val = ?", []any{"arg1", "arg2"})
 
 	patch := NewSQLPatch(obj, WithFilter(mf))
 
@@ -122,7 +125,7 @@ func (s *newSQLPatchSuite) TestNewSQLPatch_Success_Filter_JoinerAndWhere() {
 	s.Equal("JOIN table2 ON table1.id = table2.id and arg2_val = ?\n", patch.joinSql.String())
 	s.Equal([]any{"arg1"}, patch.joinArgs)
 
-	s.Equal("AND where\n", patch.whereSql.String())
+	s.Equal("AND test_where = ? and arg2_val = ?\n", patch.whereSql.String())
 	s.Equal([]any{"arg1", "arg2"}, patch.whereArgs)
 }
 
@@ -138,7 +141,7 @@ func (s *newSQLPatchSuite) TestNewSQLPatch_Success_MultiFilter() {
 	}
 
 	mf := NewMockMultiFilter(s.T())
-	mf.On("Where").Return("where", []any{"arg1", "arg2"})
+	mf.On("Where").Return("test_where = ? and arg2_val = ?", []any{"arg1", "arg2"})
 
 	patch := NewSQLPatch(obj, WithWhere(mf))
 
@@ -179,7 +182,7 @@ func (s *newSQLPatchSuite) TestNewSQLPatch_Success_MultiFilter_JoinerAndWhere()
 
 	mf := NewMockMultiFilter(s.T())
 	mf.On("Join").Return("JOIN table2 ON table1.id = table2.id", nil)
-	mf.On("Where").Return("where", []any{"arg1", "arg2"})
+	mf.On("Where").Return("test_where = ? and arg2_val = ?", []any{"arg1", "arg2"})
 
 	patch := NewSQLPatch(obj, WithJoin(mf), WithWhere(mf))
 
@@ -315,8 +318,10 @@ func (s *newSQLPatchSuite) TestNewSQLPatch_Success_MultipleTags() {
 
 	patch := NewSQLPatch(obj)
 
-	s.Equal([]string{"id_tag = ?", "name_tag = ?"}, patch.fields)
-	s.Equal([]any{1, "test"}, patch.args)
+	s.Equal([]string{"name_tag = ?"}, patch.fields)
+	s.Equal([]any{"test"}, patch.args)
+	s.Equal("AND id_tag = ?\n", patch.whereSql.String())
+	s.Equal([]any{1}, patch.whereArgs)
 }
 
 func (s *newSQLPatchSuite) TestNewSQLPatch_Success_DifferentTag() {
@@ -332,8 +337,10 @@ func (s *newSQLPatchSuite) TestNewSQLPatch_Success_DifferentTag() {
 
 	patch := NewSQLPatch(obj, WithTagName("tagged"))
 
-	s.Equal([]string{"id_tag = ?", "name_tag = ?"}, patch.fields)
-	s.Equal([]any{1, "test"}, patch.args)
+	s.Equal([]string{"name_tag = ?"}, patch.fields)
+	s.Equal([]any{"test"}, patch.args)
+	s.Equal("AND id_tag = ?\n", patch.whereSql.String())
+	s.Equal([]any{1}, patch.whereArgs)
 }
 
 func (s *newSQLPatchSuite) TestNewSQLPatch_Success_Struct_opt_IncludeNilFields() {
@@ -2088,8 +2095,8 @@ func (s *postgreSQLDialectSuite) TestGenerateSQL_PostgreSQL_WithJoin() {
 	)
 
 	s.Require().NoError(err)
-	s.Equal("UPDATE table1\nJOIN table2 ON table1.id = table2.user_id AND table2.active = $1\nSET id_tag = $2, name_tag = $3\nWHERE (1=1)\nAND (\ntable1.id = $4\n)", sqlStr)
-	s.Equal([]any{true, 1, "test", 1}, args)
+	s.Equal("UPDATE table1\nSET id_tag = $1, name_tag = $2\nFROM table2\nWHERE (1=1)\nAND (table1.id = table2.user_id AND table2.active = $3)\nAND (\ntable1.id = $4\n)", sqlStr)
+	s.Equal([]any{1, "test", true, 1}, args)
 
 	mw.AssertExpectations(s.T())
 	mj.AssertExpectations(s.T())
@@ -2188,7 +2195,7 @@ func (s *postgreSQLDialectSuite) TestGenerateSQL_PostgreSQL_HighParameterCount()
 
 	// Complex JOIN clause with parameters
 	mj := NewMockJoiner(s.T())
-	mj.On("Join").Return("JOIN accounts a ON users.account_id = a.id AND a.type = ? AND a.tier >= ? LEFT JOIN addresses addr ON users.id = addr.user_id AND addr.is_primary = ?",
+	mj.On("Join").Return("JOIN accounts a ON users.account_id = a.id AND a.type = ? AND a.tier >= ? LEFT JOIN addresses addr ON a.id = addr.account_id AND addr.is_primary = ?",
 		[]any{"premium", 5, true})
 
 	sqlStr, args, err := GenerateSQL(obj,
@@ -2201,23 +2208,24 @@ func (s *postgreSQLDialectSuite) TestGenerateSQL_PostgreSQL_HighParameterCount()
 	s.Require().NoError(err)
 
 	// Verify parameter placeholder conversion for all parameters
-	// Expected: JOIN params (3) + SET params (9) + WHERE params (5) = 17 total parameters
-
-	// Check JOIN parameters ($1, $2, $3)
-	s.Contains(sqlStr, "a.type = $1")
-	s.Contains(sqlStr, "a.tier >= $2")
-	s.Contains(sqlStr, "addr.is_primary = $3")
-
-	// Check SET parameters ($4 through $12)
-	s.Contains(sqlStr, "first_name = $4")
-	s.Contains(sqlStr, "last_name = $5")
-	s.Contains(sqlStr, "email = $6")
-	s.Contains(sqlStr, "age = $7")
-	s.Contains(sqlStr, "is_active = $8")
-	s.Contains(sqlStr, "balance = $9")
-	s.Contains(sqlStr, "country = $10")
-	s.Contains(sqlStr, "city = $11")
-	s.Contains(sqlStr, "phone_number = $12")
+	// Expected: SET params (9) + FROM params (1) + join condition params (2) + WHERE params (5) = 17 total parameters
+
+	// Check SET parameters ($1 through $9)
+	s.Contains(sqlStr, "first_name = $1")
+	s.Contains(sqlStr, "last_name = $2")
+	s.Contains(sqlStr, "email = $3")
+	s.Contains(sqlStr, "age = $4")
+	s.Contains(sqlStr, "is_active = $5")
+	s.Contains(sqlStr, "balance = $6")
+	s.Contains(sqlStr, "country = $7")
+	s.Contains(sqlStr, "city = $8")
+	s.Contains(sqlStr, "phone_number = $9")
+
+	// Check the LEFT JOIN kept in the FROM clause ($10)
+	s.Contains(sqlStr, "FROM accounts a LEFT JOIN addresses addr ON a.id = addr.account_id AND addr.is_primary = $10\n")
+
+	// Check the inner join conditions moved to the WHERE clause ($11, $12)
+	s.Contains(sqlStr, "AND (users.account_id = a.id AND a.type = $11 AND a.tier >= $12)")
 
 	// Check WHERE parameters ($13 through $17)
 	s.Contains(sqlStr, "account_id = $13")
@@ -2227,10 +2235,12 @@ func (s *postgreSQLDialectSuite) TestGenerateSQL_PostgreSQL_HighParameterCount()
 
 	// Verify all arguments are preserved in correct order
 	expectedArgs := []any{
-		// JOIN args first
-		"premium", 5, true,
 		// SET args
 		"John", "Doe", "john.doe@example.com", 30, true, 1234.56, "USA", "New York", "+1-555-123-4567",
+		// FROM args
+		true,
+		// Join condition args
+		"premium", 5,
 		// WHERE args
 		456, "2023-01-01", "active", "verified", "north",
 	}
@@ -2241,6 +2251,1288 @@ func (s *postgreSQLDialectSuite) TestGenerateSQL_PostgreSQL_HighParameterCount()
 	mj.AssertExpectations(s.T())
 }
 
-func ptrString(s string) *string    { return &s }
-func ptrBool(b bool) *bool          { return &b }
-func ptrFloat64(f float64) *float64 { return &f }
+func (s *generateSQLSuite) TestGenerateSQL_Success_QuotedIdentifiers_MySQL() {
+	type testObj struct {
+		Order   *int    `db:"order"`
+		Group   *string `db:"group"`
+		Version int     `db:"version" patcher:"version"`
+	}
+
+	sqlStr, args, err := GenerateSQL(testObj{Order: ptr(1), Group: ptr("test"), Version: 2},
+		WithTable("shop.orders"),
+		WithWhereStr("id = ?", 1),
+		WithQuotedIdentifiers(true),
+	)
+	s.Require().NoError(err)
+	s.Equal("UPDATE `shop`.`orders`\nSET `order` = ?, `group` = ?, `version` = `version` + 1\nWHERE (1=1)\nAND (\nid = ?\n)\nAND `version` = ?", sqlStr)
+	s.Equal([]any{1, "test", 1, 2}, args)
+}
+
+func (s *generateSQLSuite) TestGenerateSQL_Success_QuotedIdentifiers_PostgreSQL() {
+	type testObj struct {
+		User *string `db:"user"`
+	}
+
+	sqlStr, args, err := GenerateSQL(testObj{User: ptr("test")},
+		WithTable("public.accounts"),
+		WithWhereStr("id = ?", 1),
+		WithDialect(DialectPostgreSQL),
+		WithQuotedIdentifiers(true),
+		WithReturning("id"),
+	)
+	s.Require().NoError(err)
+	s.Equal("UPDATE \"public\".\"accounts\"\nSET \"user\" = $1\nWHERE (1=1)\nAND (\nid = $2\n)\nRETURNING \"id\"", sqlStr)
+	s.Equal([]any{"test", 1}, args)
+}
+
+type flattenSuite struct {
+	suite.Suite
+}
+
+func TestFlattenSuite(t *testing.T) {
+	suite.Run(t, new(flattenSuite))
+}
+
+type FlattenBase struct {
+	CreatedBy *string `db:"created_by"`
+}
+
+type flattenValuer struct {
+	value string
+}
+
+func (v flattenValuer) Value() (driver.Value, error) {
+	return v.value, nil
+}
+
+func (s *flattenSuite) TestNewSQLPatch_EmbeddedStruct() {
+	type testObj struct {
+		FlattenBase
+		Name *string `db:"name"`
+	}
+
+	patch := NewSQLPatch(testObj{
+		FlattenBase: FlattenBase{CreatedBy: ptr("admin")},
+		Name:        ptr("test"),
+	})
+
+	s.Equal([]string{"created_by = ?", "name = ?"}, patch.fields)
+	s.Equal([]any{"admin", "test"}, patch.args)
+}
+
+func (s *flattenSuite) TestNewSQLPatch_EmbeddedStructPointer() {
+	type testObj struct {
+		*FlattenBase
+		Name *string `db:"name"`
+	}
+
+	patch := NewSQLPatch(testObj{
+		FlattenBase: &FlattenBase{CreatedBy: ptr("admin")},
+		Name:        ptr("test"),
+	})
+
+	s.Equal([]string{"created_by = ?", "name = ?"}, patch.fields)
+	s.Equal([]any{"admin", "test"}, patch.args)
+
+	patch = NewSQLPatch(testObj{Name: ptr("test")})
+
+	s.Equal([]string{"name = ?"}, patch.fields)
+	s.Equal([]any{"test"}, patch.args)
+}
+
+func (s *flattenSuite) TestNewSQLPatch_InlineAndPrefix() {
+	type address struct {
+		City   *string `db:"city"`
+		Street *string `db:"street"`
+	}
+
+	type testObj struct {
+		Name *string  `db:"name"`
+		Home address  `patcher:"inline"`
+		Work *address `patcher:"prefix=work_"`
+	}
+
+	patch := NewSQLPatch(testObj{
+		Name: ptr("test"),
+		Home: address{City: ptr("London")},
+		Work: &address{City: ptr("Leeds"), Street: ptr("High Street")},
+	})
+
+	s.Equal([]string{"name = ?", "city = ?", "work_city = ?", "work_street = ?"}, patch.fields)
+	s.Equal([]any{"test", "London", "Leeds", "High Street"}, patch.args)
+}
+
+func (s *flattenSuite) TestNewSQLPatch_ScalarStructs() {
+	type testObj struct {
+		Name      *string       `db:"name"`
+		UpdatedAt time.Time     `db:"updated_at"`
+		Custom    flattenValuer `db:"custom" patcher:"inline"`
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	patch := NewSQLPatch(testObj{
+		Name:      ptr("test"),
+		UpdatedAt: now,
+		Custom:    flattenValuer{value: "custom"},
+	})
+
+	s.Equal([]string{"name = ?", "updated_at = ?", "custom = ?"}, patch.fields)
+	s.Equal([]any{"test", now, "custom"}, patch.args)
+}
+
+func (s *flattenSuite) TestNewDiffSQLPatch_EmbeddedStructPointer() {
+	type testObj struct {
+		*FlattenBase
+		Name *string `db:"name"`
+	}
+
+	old := testObj{
+		FlattenBase: &FlattenBase{CreatedBy: ptr("admin")},
+		Name:        ptr("test"),
+	}
+	newObj := testObj{
+		FlattenBase: &FlattenBase{CreatedBy: ptr("someone")},
+	}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"created_by = ?"}, patch.fields)
+	s.Equal([]any{"someone"}, patch.args)
+}
+
+func (s *flattenSuite) TestNewDiffSQLPatch_Prefix() {
+	type address struct {
+		City   string `db:"city"`
+		Street string `db:"street"`
+	}
+
+	type testObj struct {
+		Name string  `db:"name"`
+		Addr address `patcher:"prefix=addr_"`
+	}
+
+	old := testObj{
+		Name: "test",
+		Addr: address{City: "London", Street: "High Street"},
+	}
+	newObj := testObj{
+		Addr: address{City: "Leeds"},
+	}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"addr_city = ?"}, patch.fields)
+	s.Equal([]any{"Leeds"}, patch.args)
+}
+
+type jsonSuite struct {
+	suite.Suite
+}
+
+func TestJSONSuite(t *testing.T) {
+	suite.Run(t, new(jsonSuite))
+}
+
+func (s *jsonSuite) TestNewSQLPatch_JSON() {
+	type address struct {
+		City string `json:"city"`
+	}
+
+	type testObj struct {
+		Tags     []string       `db:"tags" patcher:"json"`
+		Metadata map[string]any `db:"metadata" patcher:"json"`
+		Address  *address       `db:"address" patcher:"json"`
+		Ignored  []string       `db:"ignored"`
+	}
+
+	patch := NewSQLPatch(testObj{
+		Tags:     []string{"a", "b"},
+		Metadata: map[string]any{"key": "value"},
+		Address:  &address{City: "London"},
+		Ignored:  []string{"c"},
+	})
+
+	s.Equal([]string{"tags = ?", "metadata = ?", "address = ?"}, patch.fields)
+	s.Equal([]any{`["a","b"]`, `{"key":"value"}`, `{"city":"London"}`}, patch.args)
+}
+
+func (s *jsonSuite) TestNewSQLPatch_JSON_NilAndEmpty() {
+	type testObj struct {
+		Tags     []string       `db:"tags" patcher:"json"`
+		Metadata map[string]any `db:"metadata" patcher:"json"`
+	}
+
+	patch := NewSQLPatch(testObj{Tags: []string{}})
+
+	s.Equal([]string{"tags = ?"}, patch.fields)
+	s.Equal([]any{"[]"}, patch.args)
+
+	patch = NewSQLPatch(testObj{}, WithIncludeNilValues(true))
+
+	s.Equal([]string{"tags = ?", "metadata = ?"}, patch.fields)
+	s.Equal([]any{nil, nil}, patch.args)
+}
+
+func (s *jsonSuite) TestGenerateSQL_JSON_EncodeError() {
+	type testObj struct {
+		Metadata map[string]any `db:"metadata" patcher:"json"`
+	}
+
+	_, _, err := GenerateSQL(testObj{Metadata: map[string]any{"fn": func() {}}},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+	)
+	s.Require().Error(err)
+}
+
+func (s *jsonSuite) TestNewDiffSQLPatch_JSON() {
+	type testObj struct {
+		Name string   `db:"name"`
+		Tags []string `db:"tags" patcher:"json"`
+	}
+
+	old := testObj{Name: "test", Tags: []string{"a"}}
+	newObj := testObj{Tags: []string{"a", "b"}}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"tags = ?"}, patch.fields)
+	s.Equal([]any{`["a","b"]`}, patch.args)
+}
+
+type nullValuerSuite struct {
+	suite.Suite
+}
+
+func TestNullValuerSuite(t *testing.T) {
+	suite.Run(t, new(nullValuerSuite))
+}
+
+func (s *nullValuerSuite) TestNewSQLPatch_NullTypes() {
+	type testObj struct {
+		Name    sql.NullString    `db:"name"`
+		Age     sql.NullInt64     `db:"age"`
+		Score   sql.Null[float64] `db:"score"`
+		Email   sql.NullString    `db:"email"`
+		Unset   sql.NullString    `db:"unset"`
+		Pointer *sql.Null[string] `db:"pointer"`
+		Nil     *sql.NullBool     `db:"nil"`
+	}
+
+	patch := NewSQLPatch(testObj{
+		Name:    sql.NullString{String: "", Valid: true},
+		Age:     sql.NullInt64{Int64: 0, Valid: true},
+		Score:   sql.Null[float64]{V: 1.5, Valid: true},
+		Email:   sql.NullString{String: "stale", Valid: false},
+		Pointer: &sql.Null[string]{V: "test", Valid: true},
+	})
+
+	s.Equal([]string{"name = ?", "age = ?", "score = ?", "pointer = ?"}, patch.fields)
+	s.Equal([]any{"", int64(0), 1.5, "test"}, patch.args)
+}
+
+func (s *nullValuerSuite) TestNewSQLPatch_NullTypes_IncludeNil() {
+	type testObj struct {
+		Name  sql.NullString `db:"name"`
+		Email sql.NullString `db:"email"`
+	}
+
+	patch := NewSQLPatch(testObj{
+		Name:  sql.NullString{String: "test", Valid: true},
+		Email: sql.NullString{String: "stale", Valid: false},
+	}, WithIncludeNilValues(true))
+
+	s.Equal([]string{"name = ?", "email = ?"}, patch.fields)
+	s.Equal([]any{"test", nil}, patch.args)
+}
+
+func (s *nullValuerSuite) TestNewSQLPatch_Valuer() {
+	type testObj struct {
+		Custom flattenValuer `db:"custom"`
+	}
+
+	patch := NewSQLPatch(testObj{Custom: flattenValuer{value: "custom"}})
+
+	s.Equal([]string{"custom = ?"}, patch.fields)
+	s.Equal([]any{"custom"}, patch.args)
+}
+
+func (s *nullValuerSuite) TestNewDiffSQLPatch_NullTypes() {
+	type testObj struct {
+		Name  sql.NullString `db:"name"`
+		Email sql.NullString `db:"email"`
+	}
+
+	old := testObj{
+		Name:  sql.NullString{String: "test", Valid: true},
+		Email: sql.NullString{String: "test@example.com", Valid: true},
+	}
+	newObj := testObj{
+		Name:  sql.NullString{String: "", Valid: true},
+		Email: sql.NullString{String: "stale", Valid: false},
+	}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"name = ?"}, patch.fields)
+	s.Equal([]any{""}, patch.args)
+	s.Equal(sql.NullString{String: "test@example.com", Valid: true}, old.Email)
+}
+
+func (s *nullValuerSuite) TestNewDiffSQLPatch_NullTypes_IncludeNil() {
+	type testObj struct {
+		Name  sql.NullString `db:"name"`
+		Email sql.NullString `db:"email"`
+	}
+
+	old := testObj{
+		Name:  sql.NullString{String: "test", Valid: true},
+		Email: sql.NullString{String: "test@example.com", Valid: true},
+	}
+	newObj := testObj{
+		Email: sql.NullString{String: "stale", Valid: false},
+	}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj, WithIncludeNilValues(true))
+	s.Require().NoError(err)
+
+	s.Equal([]string{"email = ?"}, patch.fields)
+	s.Equal([]any{nil}, patch.args)
+}
+
+type versionSuite struct {
+	suite.Suite
+}
+
+func TestVersionSuite(t *testing.T) {
+	suite.Run(t, new(versionSuite))
+}
+
+func (s *versionSuite) TestGenerateSQL_Version() {
+	type testObj struct {
+		Name    *string `db:"name"`
+		Version int     `db:"version" patcher:"version"`
+	}
+
+	sqlStr, args, err := GenerateSQL(testObj{Name: ptr("test"), Version: 3},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+	)
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test_table\nSET name = ?, version = version + 1\nWHERE (1=1)\nAND (\nid = ?\n)\nAND version = ?", sqlStr)
+	s.Equal([]any{"test", 1, 3}, args)
+}
+
+func (s *versionSuite) TestGenerateSQL_Version_ZeroValue() {
+	type testObj struct {
+		Name    *string `db:"name"`
+		Version int     `db:"version" patcher:"version"`
+	}
+
+	sqlStr, args, err := GenerateSQL(testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithDialect(DialectPostgreSQL),
+	)
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test_table\nSET name = $1, version = version + 1\nWHERE (1=1)\nAND (\nid = $2\n)\nAND version = $3", sqlStr)
+	s.Equal([]any{"test", 1, 0}, args)
+}
+
+func (s *versionSuite) TestGenerateSQL_Version_NilPointer() {
+	type testObj struct {
+		Name    *string `db:"name"`
+		Version *int    `db:"version" patcher:"version"`
+	}
+
+	sqlStr, args, err := GenerateSQL(testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+	)
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test_table\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", 1}, args)
+}
+
+func (s *versionSuite) TestNewDiffSQLPatch_Version() {
+	type testObj struct {
+		Name    *string `db:"name"`
+		Version int     `db:"version" patcher:"version"`
+	}
+
+	old := testObj{Name: ptr("test"), Version: 7}
+	newObj := testObj{Name: ptr("test2")}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj,
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+	)
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test_table\nSET name = ?, version = version + 1\nWHERE (1=1)\nAND (\nid = ?\n)\nAND version = ?", sqlStr)
+	s.Equal([]any{"test2", 1, 7}, args)
+}
+
+func (s *versionSuite) TestPerformPatch_StaleObject() {
+	type testObj struct {
+		Name    *string `db:"name"`
+		Version int     `db:"version" patcher:"version"`
+	}
+
+	exec := NewMockExecutor(s.T())
+	exec.On("ExecContext", mock.Anything, mock.Anything, "test", 1, 3).Return(driver.RowsAffected(0), nil)
+
+	res, err := PerformPatch(testObj{Name: ptr("test"), Version: 3},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithExecutor(exec),
+	)
+	s.Require().ErrorIs(err, ErrStaleObject)
+	s.Nil(res)
+}
+
+func (s *versionSuite) TestPerformPatch_Versioned() {
+	type testObj struct {
+		Name    *string `db:"name"`
+		Version int     `db:"version" patcher:"version"`
+	}
+
+	exec := NewMockExecutor(s.T())
+	exec.On("ExecContext", mock.Anything, mock.Anything, "test", 1, 3).Return(driver.RowsAffected(1), nil)
+
+	res, err := PerformPatch(testObj{Name: ptr("test"), Version: 3},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithExecutor(exec),
+	)
+	s.Require().NoError(err)
+	s.NotNil(res)
+}
+
+type performPatchSuite struct {
+	suite.Suite
+}
+
+func TestPerformPatchSuite(t *testing.T) {
+	suite.Run(t, new(performPatchSuite))
+}
+
+func (s *performPatchSuite) TestPerformPatchContext_NoDatabaseConnection() {
+	type testObj struct {
+		Id   *int    `db:"id"`
+		Name *string `db:"name"`
+	}
+
+	obj := testObj{
+		Id:   ptr(1),
+		Name: ptr("test"),
+	}
+
+	res, err := PerformPatchContext(context.Background(), obj,
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+	)
+	s.Require().ErrorIs(err, ErrNoDatabaseConnection)
+	s.Nil(res)
+}
+
+func (s *performPatchSuite) TestPerformDiffPatchContext_NoChanges() {
+	type testObj struct {
+		Id   *int    `db:"id"`
+		Name *string `db:"name"`
+	}
+
+	old := testObj{Id: ptr(1), Name: ptr("test")}
+	newObj := testObj{Id: ptr(1), Name: ptr("test")}
+
+	res, err := PerformDiffPatchContext(context.Background(), &old, &newObj,
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+	)
+	s.Require().ErrorIs(err, ErrNoChanges)
+	s.Nil(res)
+}
+
+func (s *performPatchSuite) TestPerformPatchContext_Executor() {
+	type testObj struct {
+		Name *string `db:"name"`
+	}
+
+	ctx := context.Background()
+
+	exec := NewMockExecutor(s.T())
+	exec.On("ExecContext", ctx, "UPDATE test_table\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)", "test", 1).
+		Return(driver.RowsAffected(1), nil)
+
+	res, err := PerformPatchContext(ctx, testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithExecutor(exec),
+	)
+	s.Require().NoError(err)
+
+	affected, err := res.RowsAffected()
+	s.Require().NoError(err)
+	s.Equal(int64(1), affected)
+}
+
+func (s *performPatchSuite) TestPerformPatchContext_Timeout() {
+	type testObj struct {
+		Name *string `db:"name"`
+	}
+
+	exec := NewMockExecutor(s.T())
+	exec.On("ExecContext", mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	}), mock.Anything, "test", 1).Return(driver.RowsAffected(1), nil)
+
+	_, err := PerformPatchContext(context.Background(), testObj{Name: ptr("test")},
+		WithTable("test_table"),
+		WithWhereStr("id = ?", 1),
+		WithExecutor(exec),
+		WithTimeout(time.Second),
+	)
+	s.Require().NoError(err)
+}
+
+func (s *performPatchSuite) TestExecutor_Implementations() {
+	s.Implements((*Executor)(nil), new(sql.DB))
+	s.Implements((*Executor)(nil), new(sql.Tx))
+	s.Implements((*Executor)(nil), new(sql.Conn))
+}
+
+func (s *performPatchSuite) TestWithTimeout() {
+	type testObj struct {
+		Name *string `db:"name"`
+	}
+
+	patch := NewSQLPatch(testObj{Name: ptr("test")}, WithTimeout(5*time.Second))
+
+	s.Equal(5*time.Second, patch.timeout)
+}
+
+func ptrString(s string) *string    { return &s }
+func ptrBool(b bool) *bool          { return &b }
+func ptrFloat64(f float64) *float64 { return &f }
+
+type fieldMaskSuite struct {
+	suite.Suite
+}
+
+func TestFieldMaskSuite(t *testing.T) {
+	suite.Run(t, new(fieldMaskSuite))
+}
+
+type fieldMaskAddress struct {
+	Street string  `db:"street" json:"street"`
+	City   *string `db:"city" json:"city"`
+}
+
+type fieldMaskUser struct {
+	FlattenBase
+	ID      int              `db:"id" json:"id"`
+	Name    string           `db:"name" json:"name"`
+	Email   *string          `db:"email" json:"email"`
+	Age     int              `db:"age" json:"age"`
+	Address fieldMaskAddress `db:"address" json:"address" patcher:"prefix=address_"`
+}
+
+func (s *fieldMaskSuite) TestNewSQLPatch_IncludesZeroAndNil() {
+	patch := NewSQLPatch(&fieldMaskUser{
+		ID:   1,
+		Name: "",
+		Age:  30,
+	}, WithFieldMask("name", "Email"))
+
+	s.Require().NoError(patch.genErr)
+	s.Equal([]string{"name = ?", "email = ?"}, patch.fields)
+	s.Equal([]any{"", nil}, patch.args)
+}
+
+func (s *fieldMaskSuite) TestNewSQLPatch_NestedPath() {
+	patch := NewSQLPatch(&fieldMaskUser{
+		Name: "test",
+		Address: fieldMaskAddress{
+			Street: "Main Street",
+		},
+	}, WithFieldMask("address.city"))
+
+	s.Require().NoError(patch.genErr)
+	s.Equal([]string{"address_city = ?"}, patch.fields)
+	s.Equal([]any{nil}, patch.args)
+}
+
+func (s *fieldMaskSuite) TestNewSQLPatch_ParentPath() {
+	patch := NewSQLPatch(&fieldMaskUser{
+		Address: fieldMaskAddress{
+			Street: "Main Street",
+		},
+	}, WithFieldMask("address"))
+
+	s.Require().NoError(patch.genErr)
+	s.Equal([]string{"address_street = ?", "address_city = ?"}, patch.fields)
+	s.Equal([]any{"Main Street", nil}, patch.args)
+}
+
+func (s *fieldMaskSuite) TestNewSQLPatch_PromotedField() {
+	patch := NewSQLPatch(&fieldMaskUser{
+		Name: "test",
+	}, WithFieldMask("created_by"))
+
+	s.Require().NoError(patch.genErr)
+	s.Equal([]string{"created_by = ?"}, patch.fields)
+	s.Equal([]any{nil}, patch.args)
+}
+
+func (s *fieldMaskSuite) TestNewSQLPatch_UnknownPath() {
+	patch := NewSQLPatch(&fieldMaskUser{
+		Name: "test",
+	}, WithTable("users"), WithWhereStr("id = ?", 1), WithFieldMask("name", "address.country"))
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().ErrorIs(err, ErrInvalidFieldMask)
+	s.Empty(sqlStr)
+	s.Nil(args)
+}
+
+func (s *fieldMaskSuite) TestNewSQLPatch_PathThroughScalar() {
+	patch := NewSQLPatch(&fieldMaskUser{
+		Name: "test",
+	}, WithFieldMask("name.first"))
+
+	s.Require().ErrorIs(patch.genErr, ErrInvalidFieldMask)
+}
+
+// fieldMaskProfile nests an address that is not flattened, so it is stored as a single column
+type fieldMaskProfile struct {
+	Name    string           `db:"name" json:"name"`
+	Address fieldMaskAddress `db:"address" json:"address"`
+}
+
+func (s *fieldMaskSuite) TestNewSQLPatch_PathThroughNestedStruct() {
+	patch := NewSQLPatch(&fieldMaskProfile{
+		Name: "test",
+	}, WithFieldMask("address.city"))
+
+	s.Require().ErrorIs(patch.genErr, ErrInvalidFieldMask)
+	s.ErrorContains(patch.genErr, "Address is stored as a single column")
+
+	patch = NewSQLPatch(&fieldMaskProfile{
+		Name: "test",
+	}, WithFieldMask("address"))
+
+	s.Require().NoError(patch.genErr)
+	s.Equal([]string{"address = ?"}, patch.fields)
+	s.Equal([]any{fieldMaskAddress{}}, patch.args)
+}
+
+func (s *fieldMaskSuite) TestNewDiffSQLPatch_PathThroughNestedStruct() {
+	old := fieldMaskProfile{Name: "John"}
+	newObj := fieldMaskProfile{Name: "Jane", Address: fieldMaskAddress{Street: "Main Street"}}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj, WithFieldMask("address.city"))
+	s.Require().ErrorIs(err, ErrInvalidFieldMask)
+	s.Nil(patch)
+	s.Equal("John", old.Name)
+}
+
+func (s *fieldMaskSuite) TestLoadDiff() {
+	email := "old@example.com"
+	old := fieldMaskUser{
+		ID:    1,
+		Name:  "John",
+		Email: &email,
+		Age:   30,
+	}
+	newObj := fieldMaskUser{
+		Name: "Jane",
+		Age:  0,
+	}
+
+	err := LoadDiff(&old, &newObj, WithFieldMask("email", "age"))
+	s.Require().NoError(err)
+
+	s.Equal("John", old.Name)
+	s.Nil(old.Email)
+	s.Equal(0, old.Age)
+	s.Equal(1, old.ID)
+}
+
+func (s *fieldMaskSuite) TestLoadDiff_UnknownPath() {
+	old := fieldMaskUser{Name: "John"}
+	newObj := fieldMaskUser{Name: "Jane"}
+
+	err := LoadDiff(&old, &newObj, WithFieldMask("nickname"))
+	s.Require().ErrorIs(err, ErrInvalidFieldMask)
+	s.Equal("John", old.Name)
+}
+
+func (s *fieldMaskSuite) TestNewDiffSQLPatch() {
+	city := "London"
+	old := fieldMaskUser{
+		ID:   1,
+		Name: "John",
+		Age:  30,
+		Address: fieldMaskAddress{
+			Street: "Main Street",
+			City:   &city,
+		},
+	}
+	newObj := fieldMaskUser{
+		Name: "Jane",
+		Age:  0,
+	}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj, WithFieldMask("age", "address.city"))
+	s.Require().NoError(err)
+
+	s.Equal([]string{"age = ?", "address_city = ?"}, patch.fields)
+	s.Equal([]any{0, nil}, patch.args)
+	s.Equal("John", old.Name)
+	s.Equal("Main Street", old.Address.Street)
+}
+
+func (s *fieldMaskSuite) TestNewDiffSQLPatch_UnknownPath() {
+	old := fieldMaskUser{Name: "John"}
+	newObj := fieldMaskUser{Name: "Jane"}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj, WithFieldMask("unknown"))
+	s.Require().ErrorIs(err, ErrInvalidFieldMask)
+	s.Nil(patch)
+}
+
+type primaryKeySuite struct {
+	suite.Suite
+}
+
+func TestPrimaryKeySuite(t *testing.T) {
+	suite.Run(t, new(primaryKeySuite))
+}
+
+func (s *primaryKeySuite) TestGenerateSQL() {
+	type testObj struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{ID: 1, Name: "test"}, WithTable("test")).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", 1}, args)
+}
+
+func (s *primaryKeySuite) TestGenerateSQL_CompositeKey() {
+	type testObj struct {
+		TenantID string `db:"tenant_id,pk"`
+		ID       *int   `db:"id,pk"`
+		Name     string `db:"name"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{TenantID: "acme", ID: ptr(1), Name: "test"},
+		WithTable("test"),
+		WithDialect(DialectPostgreSQL),
+		WithQuotedIdentifiers(true),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE \"test\"\nSET \"name\" = $1\nWHERE (1=1)\nAND (\n\"tenant_id\" = $2\nAND \"id\" = $3\n)", sqlStr)
+	s.Equal([]any{"test", "acme", 1}, args)
+}
+
+func (s *primaryKeySuite) TestGenerateSQL_ExplicitFilter() {
+	type testObj struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{Name: "test"},
+		WithTable("test"),
+		WithWhereStr("name = ?", "old"),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test\nSET name = ?\nWHERE (1=1)\nAND (\nname = ?\n)", sqlStr)
+	s.Equal([]any{"test", "old"}, args)
+}
+
+func (s *primaryKeySuite) TestGenerateSQL_ZeroKey() {
+	type testObj struct {
+		TenantID string `db:"tenant_id,pk"`
+		ID       int    `db:"id,pk"`
+		Name     string `db:"name"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{TenantID: "acme", Name: "test"}, WithTable("test")).GenerateSQL()
+	s.Require().ErrorIs(err, ErrZeroPrimaryKey)
+	s.ErrorContains(err, "ID")
+	s.Empty(sqlStr)
+	s.Nil(args)
+}
+
+func (s *primaryKeySuite) TestGenerateSQL_EmptyGroup() {
+	type keyedObj struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	type testObj struct {
+		Name string `db:"name"`
+	}
+
+	for name, group := range map[string]*WhereGroup{"And": AndGroup(), "Or": OrGroup()} {
+		s.Run(name, func() {
+			// An empty group does not replace the where clause generated from the primary keys
+			sqlStr, args, err := NewSQLPatch(&keyedObj{Name: "test"},
+				WithTable("test"),
+				WithWhere(group),
+			).GenerateSQL()
+			s.Require().ErrorIs(err, ErrZeroPrimaryKey)
+			s.Empty(sqlStr)
+			s.Nil(args)
+
+			sqlStr, args, err = NewSQLPatch(&testObj{Name: "test"},
+				WithTable("test"),
+				WithWhere(group),
+			).GenerateSQL()
+			s.Require().ErrorIs(err, ErrNoWhere)
+			s.Empty(sqlStr)
+			s.Nil(args)
+		})
+	}
+}
+
+func (s *primaryKeySuite) TestGenerateSQL_IncludeZeroValues() {
+	type testObj struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+		Age  int    `db:"age"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{ID: 1, Name: "test"},
+		WithTable("test"),
+		WithIncludeZeroValues(true),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test\nSET name = ?, age = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", 0, 1}, args)
+}
+
+func (s *primaryKeySuite) TestNewDiffSQLPatch() {
+	type testObj struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	old := testObj{ID: 1, Name: "old"}
+	newObj := testObj{Name: "new"}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj, WithTable("test"))
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"new", 1}, args)
+}
+
+func (s *primaryKeySuite) TestNewSQLPatchFromMergePatch() {
+	type testObj struct {
+		ID   int    `db:"id,pk" json:"id"`
+		Name string `db:"name" json:"name"`
+	}
+
+	patch, err := NewSQLPatchFromMergePatch[testObj]([]byte(`{"id": 1, "name": "test"}`), WithTable("test"))
+	s.Require().NoError(err)
+
+	sqlStr, args, err := patch.GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE test\nSET name = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", 1}, args)
+}
+
+func (s *primaryKeySuite) TestNewSQLPatchFromMergePatch_NullKey() {
+	type testObj struct {
+		ID   int    `db:"id,pk" json:"id"`
+		Name string `db:"name" json:"name"`
+	}
+
+	patch, err := NewSQLPatchFromMergePatch[testObj]([]byte(`{"id": null, "name": "test"}`), WithTable("test"))
+	s.Require().ErrorIs(err, ErrZeroPrimaryKey)
+	s.Nil(patch)
+}
+
+type exprSuite struct {
+	suite.Suite
+}
+
+func TestExprSuite(t *testing.T) {
+	suite.Run(t, new(exprSuite))
+}
+
+func (s *exprSuite) TestGenerateSQL_StructField() {
+	type testObj struct {
+		Name       string     `db:"name"`
+		LoginCount Expression `db:"login_count"`
+		Score      int        `db:"score"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{
+		Name:       "test",
+		LoginCount: Expr("login_count + ?", 1),
+		Score:      10,
+	}, WithTable("users"), WithWhereStr("id = ?", 5)).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, login_count = login_count + ?, score = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", 1, 10, 5}, args)
+}
+
+func (s *exprSuite) TestGenerateSQL_WithSet() {
+	type testObj struct {
+		Name      string `db:"name"`
+		UpdatedAt string `db:"updated_at"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{
+		Name:      "test",
+		UpdatedAt: "ignored",
+	},
+		WithTable("users"),
+		WithWhereStr("id = ?", 5),
+		WithSet("updated_at", Expr("CURRENT_TIMESTAMP")),
+		WithSet("score", Expr("GREATEST(score, ?)", 10)),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, updated_at = CURRENT_TIMESTAMP, score = GREATEST(score, ?)\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", 10, 5}, args)
+}
+
+func (s *exprSuite) TestGenerateSQL_OnlyExpressionsWithoutArgs() {
+	type testObj struct {
+		Name string `db:"name"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{},
+		WithTable("users"),
+		WithWhereStr("id = ?", 5),
+		WithSet("updated_at", Expr("NOW()")),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET updated_at = NOW()\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{5}, args)
+}
+
+func (s *exprSuite) TestGenerateSQL_Increment() {
+	type testObj struct {
+		Name       string `db:"name"`
+		LoginCount int    `db:"login_count" patcher:"increment"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{
+		Name:       "test",
+		LoginCount: 1,
+	}, WithTable("users"), WithWhereStr("id = ?", 5)).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, login_count = login_count + ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", 1, 5}, args)
+}
+
+func (s *exprSuite) TestGenerateSQL_PostgreSQL() {
+	type testObj struct {
+		Name       string     `db:"name"`
+		LoginCount int        `db:"login_count" patcher:"increment"`
+		Score      Expression `db:"score"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{
+		Name:       "test",
+		LoginCount: 2,
+		Score:      Expr("LEAST(score + ?, ?)", 5, 100),
+	},
+		WithTable("users"),
+		WithJoinStr("JOIN teams t ON t.id = users.team_id AND t.name = ?", "team"),
+		WithWhereStr("users.id = ?", 7),
+		WithDialect(DialectPostgreSQL),
+		WithQuotedIdentifiers(true),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE \"users\"\n"+
+		"SET \"name\" = $1, \"login_count\" = \"login_count\" + $2, \"score\" = LEAST(score + $3, $4)\n"+
+		"FROM teams t\n"+
+		"WHERE (1=1)\nAND (t.id = users.team_id AND t.name = $5)\nAND (\nusers.id = $6\n)", sqlStr)
+	s.Equal([]any{"test", 2, 5, 100, "team", 7}, args)
+}
+
+func (s *exprSuite) TestGenerateSQL_PostgreSQL_NumberedPlaceholders() {
+	type testObj struct {
+		Name  string     `db:"name"`
+		Score Expression `db:"score"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&testObj{
+		Name:  "a",
+		Score: Expr("LEAST(score + $2, $1)", 100, 5),
+	},
+		WithTable("users"),
+		WithWhereStr("id = $1", 1),
+		WithSet("cnt", Expr("cnt + $1", 5)),
+		WithDialect(DialectPostgreSQL),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\n"+
+		"SET name = $1, score = LEAST(score + $2, $3), cnt = cnt + $4\n"+
+		"WHERE (1=1)\nAND (\nid = $5\n)", sqlStr)
+	s.Equal([]any{"a", 5, 100, 5, 1}, args)
+}
+
+func (s *exprSuite) TestGenerateSQL_PlaceholderMismatch() {
+	type testObj struct {
+		Name string `db:"name"`
+	}
+
+	for name, expr := range map[string]Expression{
+		"Numbered":      Expr("cnt + $2", 5),
+		"Question mark": Expr("cnt + ? + ?", 5),
+	} {
+		s.Run(name, func() {
+			sqlStr, args, err := NewSQLPatch(&testObj{Name: "a"},
+				WithTable("users"),
+				WithWhereStr("id = ?", 1),
+				WithSet("cnt", expr),
+			).GenerateSQL()
+			s.Require().ErrorIs(err, ErrPlaceholderMismatch)
+			s.ErrorContains(err, "SET cnt")
+			s.Empty(sqlStr)
+			s.Nil(args)
+		})
+	}
+}
+
+func (s *exprSuite) TestNewDiffSQLPatch() {
+	type testObj struct {
+		Name       string     `db:"name"`
+		LoginCount Expression `db:"login_count"`
+	}
+
+	old := testObj{Name: "test"}
+	newObj := testObj{LoginCount: Expr("login_count + ?", 1)}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"login_count = login_count + ?"}, patch.fields)
+	s.Equal([]any{1}, patch.args)
+}
+
+func (s *exprSuite) TestNewSQLPatchFromMergePatch_Increment() {
+	type testObj struct {
+		Name       string `db:"name" json:"name"`
+		LoginCount int    `db:"login_count" json:"login_count" patcher:"increment"`
+	}
+
+	patch, err := NewSQLPatchFromMergePatch[testObj]([]byte(`{"login_count": 3}`),
+		WithSet("updated_at", Expr("NOW()")),
+	)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"login_count = login_count + ?", "updated_at = NOW()"}, patch.fields)
+	s.Equal([]any{3}, patch.args)
+}
+
+type timestampSuite struct {
+	suite.Suite
+
+	now time.Time
+}
+
+func TestTimestampSuite(t *testing.T) {
+	suite.Run(t, new(timestampSuite))
+}
+
+func (s *timestampSuite) SetupTest() {
+	s.now = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+}
+
+func (s *timestampSuite) clock() time.Time {
+	return s.now
+}
+
+type timestampUser struct {
+	ID        int        `db:"id,pk" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at" patcher:"autocreate"`
+	UpdatedAt *time.Time `db:"updated_at" json:"updated_at" patcher:"autoupdate"`
+}
+
+func (s *timestampSuite) TestGenerateSQL() {
+	sqlStr, args, err := NewSQLPatch(&timestampUser{
+		ID:        1,
+		Name:      "test",
+		CreatedAt: s.now.Add(-time.Hour),
+	}, WithTable("users"), WithClock(s.clock)).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, updated_at = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", s.now, 1}, args)
+}
+
+func (s *timestampSuite) TestGenerateSQL_OnlyTimestamp() {
+	sqlStr, args, err := NewSQLPatch(&timestampUser{ID: 1}, WithTable("users"), WithClock(s.clock)).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET updated_at = ?\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{s.now, 1}, args)
+}
+
+func (s *timestampSuite) TestGenerateSQL_Database() {
+	sqlStr, args, err := NewSQLPatch(&timestampUser{
+		ID:   1,
+		Name: "test",
+	},
+		WithTable("users"),
+		WithTimestampSource(TimestampSourceDatabase),
+		WithDialect(DialectPostgreSQL),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = $1, updated_at = CURRENT_TIMESTAMP\nWHERE (1=1)\nAND (\nid = $2\n)", sqlStr)
+	s.Equal([]any{"test", 1}, args)
+}
+
+func (s *timestampSuite) TestGenerateSQL_WithSetOverride() {
+	sqlStr, args, err := NewSQLPatch(&timestampUser{
+		ID:   1,
+		Name: "test",
+	},
+		WithTable("users"),
+		WithClock(s.clock),
+		WithSet("updated_at", Expr("NOW()")),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\nSET name = ?, updated_at = NOW()\nWHERE (1=1)\nAND (\nid = ?\n)", sqlStr)
+	s.Equal([]any{"test", 1}, args)
+}
+
+func (s *timestampSuite) TestNewDiffSQLPatch() {
+	updatedAt := s.now.Add(-time.Hour)
+	old := timestampUser{ID: 1, Name: "old", UpdatedAt: &updatedAt}
+	newObj := timestampUser{Name: "new"}
+
+	patch, err := NewDiffSQLPatch(&old, &newObj, WithClock(s.clock))
+	s.Require().NoError(err)
+
+	s.Equal([]string{"name = ?", "updated_at = ?"}, patch.fields)
+	s.Equal([]any{"new", s.now}, patch.args)
+}
+
+func (s *timestampSuite) TestNewSQLPatchFromMergePatch() {
+	patch, err := NewSQLPatchFromMergePatch[timestampUser](
+		[]byte(`{"id": 1, "name": "test", "created_at": "2020-01-01T00:00:00Z", "updated_at": null}`),
+		WithClock(s.clock),
+	)
+	s.Require().NoError(err)
+
+	s.Equal([]string{"name = ?", "updated_at = ?"}, patch.fields)
+	s.Equal([]any{"test", s.now}, patch.args)
+}
+
+type benchmarkAddress struct {
+	Street string `db:"street"`
+	City   string `db:"city"`
+}
+
+type benchmarkUser struct {
+	ID        int              `db:"id"`
+	Name      string           `db:"name"`
+	Email     *string          `db:"email"`
+	Age       int              `db:"age" patcher:"omitempty"`
+	Active    bool             `db:"active"`
+	Score     float64          `db:"score"`
+	Nickname  *string          `db:"nickname"`
+	CreatedAt time.Time        `db:"created_at"`
+	Address   benchmarkAddress `patcher:"prefix=address_"`
+}
+
+func newBenchmarkUser() *benchmarkUser {
+	return &benchmarkUser{
+		ID:        1,
+		Name:      "John",
+		Email:     ptr("john@example.com"),
+		Active:    true,
+		Score:     12.5,
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Address: benchmarkAddress{
+			Street: "Main Street",
+			City:   "London",
+		},
+	}
+}
+
+func BenchmarkNewSQLPatch(b *testing.B) {
+	user := newBenchmarkUser()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		NewSQLPatch(user, WithTable("users"), WithWhereStr("id = ?", 1))
+	}
+}
+
+func BenchmarkGenerateSQL(b *testing.B) {
+	user := newBenchmarkUser()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, err := NewSQLPatch(user, WithTable("users"), WithWhereStr("id = ?", 1)).GenerateSQL(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateSQL_PostgreSQL(b *testing.B) {
+	user := newBenchmarkUser()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _, err := NewSQLPatch(user,
+			WithTable("users"),
+			WithWhereStr("id = ?", 1),
+			WithDialect(DialectPostgreSQL),
+			WithQuotedIdentifiers(true),
+		).GenerateSQL()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNewDiffSQLPatch(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		old := newBenchmarkUser()
+		newUser := benchmarkUser{Name: "Jane", Address: benchmarkAddress{City: "Paris"}}
+		if _, err := NewDiffSQLPatch(old, &newUser, WithTable("users"), WithWhereStr("id = ?", 1)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package patcher
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
+)
+
+// CurrentTimestamp is the SQL expression used to set automatic timestamps when TimestampSourceDatabase is used
+const CurrentTimestamp = "CURRENT_TIMESTAMP"
+
+// Clock returns the current time. It is used to generate automatic timestamps and can be replaced to make the
+// generated SQL deterministic, such as in tests.
+type Clock func() time.Time
+
+// TimestampSource determines where the automatic timestamps of fields tagged with autocreate and autoupdate are
+// generated.
+type TimestampSource int
+
+const (
+	// TimestampSourceClock generates the timestamps in Go using the Clock and binds them as arguments (default)
+	TimestampSourceClock TimestampSource = iota
+
+	// TimestampSourceDatabase lets the database generate the timestamps using the CURRENT_TIMESTAMP expression
+	TimestampSourceDatabase
+)
+
+// now returns the current time from the clock
+func (s *SQLPatch) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+
+	return s.clock()
+}
+
+// timestampGen appends the SET clauses for the fields of the struct type tagged with autoupdate. These fields are
+// always set to the current time, regardless of their value.
+func (s *SQLPatch) timestampGen(typeOf reflect.Type, prefix string) {
+	fields := fieldmeta.TypeFields(typeOf, s.tagName)
+	for i := range fields {
+		meta := &fields[i]
+		if !meta.Field.IsExported() || meta.Options.Has(fieldmeta.OptSkip) {
+			continue
+		}
+
+		if meta.Flatten {
+			nestedType := meta.Field.Type
+			if nestedType.Kind() == reflect.Ptr {
+				nestedType = nestedType.Elem()
+			}
+
+			s.timestampGen(nestedType, prefix+meta.Prefix)
+			continue
+		}
+
+		if !meta.Options.Has(fieldmeta.OptAutoUpdate) {
+			continue
+		}
+
+		column := prefix + meta.Column
+		if s.hasSet(column) {
+			continue
+		}
+
+		if s.timestampSource == TimestampSourceDatabase {
+			s.exprGen(column, Expr(CurrentTimestamp))
+			continue
+		}
+
+		s.fields = append(s.fields, s.quote(column)+" = ?")
+		s.args = append(s.args, s.now())
+	}
+}
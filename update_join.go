@@ -0,0 +1,320 @@
+package patcher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedJoin is returned when a join cannot be expressed in an UPDATE statement of the SQL dialect
+var ErrUnsupportedJoin = errors.New("join is not supported by the SQL dialect")
+
+// UpdateJoinStyle is the way the joins of an UPDATE statement are written in a dialect
+type UpdateJoinStyle int
+
+const (
+	// UpdateJoinInline writes the joins after the updated table, as in the MySQL multi-table UPDATE
+	UpdateJoinInline UpdateJoinStyle = iota
+
+	// UpdateJoinFrom lists the joined tables in a FROM clause and moves the join conditions to the WHERE clause, as
+	// in PostgreSQL and SQLite
+	UpdateJoinFrom
+
+	// UpdateJoinFromTarget repeats the updated table in a FROM clause followed by the joins, as in SQL Server
+	UpdateJoinFromTarget
+
+	// UpdateJoinUnsupported is used by dialects that cannot join tables in an UPDATE statement
+	UpdateJoinUnsupported
+)
+
+// UpdateJoinStyle returns the way the joins of an UPDATE statement are written in the dialect
+func (d SQLDialect) UpdateJoinStyle() UpdateJoinStyle {
+	switch d {
+	case DialectPostgreSQL, DialectSQLite:
+		return UpdateJoinFrom
+	case DialectSQLServer:
+		return UpdateJoinFromTarget
+	case DialectOracle:
+		return UpdateJoinUnsupported
+	default:
+		return UpdateJoinInline
+	}
+}
+
+// referencesTable checks if the SQL references a column of the table, such as users.id. The table is matched by the
+// last part of its name, case-insensitively and with or without identifier quotes.
+func referencesTable(sqlStr, table string) bool {
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		table = table[i+1:]
+	}
+	name := strings.ToLower(strings.Trim(table, "\"`[]"))
+	lower := strings.ToLower(sqlStr)
+
+	for _, ref := range []string{name + ".", `"` + name + `".`, "`" + name + "`.", "[" + name + "]."} {
+		for i := 0; i < len(lower); {
+			j := strings.Index(lower[i:], ref)
+			if j < 0 {
+				break
+			}
+
+			j += i
+			if j == 0 || !isIdentByte(lower[j-1]) {
+				return true
+			}
+			i = j + 1
+		}
+	}
+
+	return false
+}
+
+// updateJoin is a join of the JOIN clause, split into the parts needed to render it in a FROM clause
+type updateJoin struct {
+	// left determines whether the join is a LEFT JOIN, which is kept as a join within the FROM clause
+	left bool
+
+	// table is the joined table, including its alias
+	table     string
+	tableArgs []any
+
+	// on is the condition of the join, without the ON keyword
+	on     string
+	onArgs []any
+}
+
+// fromClause is the FROM clause and the join conditions of an UPDATE statement for the dialects joining tables
+// with a FROM clause. Inner joins are listed in the FROM clause with their conditions moved to the WHERE clause,
+// while left joins are kept as joins on the table listed before them.
+type fromClause struct {
+	from       string
+	fromArgs   []any
+	conditions []string
+	condArgs   []any
+}
+
+// newFromClause builds the FROM clause from the joins added to the patch. The updated table cannot be referenced
+// within the FROM clause, so a LEFT JOIN whose condition references it results in ErrUnsupportedJoin.
+func newFromClause(table string, entries []joinEntry, syntax sqlSyntax) (*fromClause, error) {
+	joins := make([]updateJoin, 0, len(entries))
+	for _, entry := range entries {
+		parsed, err := parseJoins(entry.sql, entry.args, syntax)
+		if err != nil {
+			return nil, err
+		}
+		joins = append(joins, parsed...)
+	}
+
+	clause := &fromClause{
+		fromArgs: make([]any, 0),
+		condArgs: make([]any, 0),
+	}
+
+	from := new(strings.Builder)
+	for i, join := range joins {
+		if join.left {
+			if i == 0 {
+				return nil, fmt.Errorf("%w: the first join cannot be a LEFT JOIN: %s", ErrUnsupportedJoin, join.table)
+			}
+
+			if referencesTable(join.on, table) {
+				return nil, fmt.Errorf("%w: the LEFT JOIN %s cannot reference the updated table %s", ErrUnsupportedJoin,
+					join.table, table)
+			}
+
+			from.WriteString(" LEFT JOIN ")
+			from.WriteString(join.table)
+			clause.fromArgs = append(clause.fromArgs, join.tableArgs...)
+			if join.on != "" {
+				from.WriteString(" ON ")
+				from.WriteString(join.on)
+				clause.fromArgs = append(clause.fromArgs, join.onArgs...)
+			}
+			continue
+		}
+
+		if i > 0 {
+			from.WriteString(", ")
+		}
+		from.WriteString(join.table)
+		clause.fromArgs = append(clause.fromArgs, join.tableArgs...)
+
+		if join.on != "" {
+			clause.conditions = append(clause.conditions, join.on)
+			clause.condArgs = append(clause.condArgs, join.onArgs...)
+		}
+	}
+
+	clause.from = from.String()
+	return clause, nil
+}
+
+// joinModifiers are the keywords that can precede JOIN
+var joinModifiers = map[string]struct{}{
+	"INNER":   {},
+	"LEFT":    {},
+	"RIGHT":   {},
+	"FULL":    {},
+	"OUTER":   {},
+	"CROSS":   {},
+	"NATURAL": {},
+}
+
+// sqlWord is a word of a SQL string outside any parentheses or quotes
+type sqlWord struct {
+	word       string
+	start, end int
+}
+
+// parseJoins splits the JOIN clause into its joins, distributing the arguments over the table and condition of each
+// join by counting their ? placeholders. The quoted parts of the clause are read with the given syntax.
+func parseJoins(sqlStr string, args []any, syntax sqlSyntax) ([]updateJoin, error) {
+	words := topLevelWords(sqlStr, syntax)
+
+	// starts are the indexes of the first word of each join, including the keywords preceding JOIN
+	starts := make([]int, 0)
+	for i, w := range words {
+		if !strings.EqualFold(w.word, "JOIN") {
+			continue
+		}
+
+		start := i
+		for start > 0 {
+			if _, ok := joinModifiers[strings.ToUpper(words[start-1].word)]; !ok {
+				break
+			}
+			start--
+		}
+		starts = append(starts, start)
+	}
+
+	if len(starts) == 0 || strings.TrimSpace(sqlStr[:words[starts[0]].start]) != "" {
+		return nil, fmt.Errorf("%w: expected a JOIN keyword: %s", ErrUnsupportedJoin, sqlStr)
+	}
+
+	joins := make([]updateJoin, 0, len(starts))
+	for n, start := range starts {
+		// The join ends at the first word of the next join
+		end, endPos := len(words), len(sqlStr)
+		if n+1 < len(starts) {
+			end = starts[n+1]
+			endPos = words[end].start
+		}
+
+		join, err := parseJoin(sqlStr, words[start:end], endPos)
+		if err != nil {
+			return nil, err
+		}
+
+		joins = append(joins, join)
+	}
+
+	for i := range joins {
+		var ok bool
+		if joins[i].tableArgs, args, ok = takeArgs(joins[i].table, args, syntax); !ok {
+			return nil, fmt.Errorf("%w: placeholders do not match the arguments: %s", ErrUnsupportedJoin, sqlStr)
+		}
+		if joins[i].onArgs, args, ok = takeArgs(joins[i].on, args, syntax); !ok {
+			return nil, fmt.Errorf("%w: placeholders do not match the arguments: %s", ErrUnsupportedJoin, sqlStr)
+		}
+	}
+
+	if len(args) > 0 {
+		return nil, fmt.Errorf("%w: placeholders do not match the arguments: %s", ErrUnsupportedJoin, sqlStr)
+	}
+
+	return joins, nil
+}
+
+// parseJoin parses a single join from its words, ending at the given position of the SQL string
+func parseJoin(sqlStr string, words []sqlWord, end int) (updateJoin, error) {
+	keywords := make([]string, 0, 3)
+	i := 0
+	for ; i < len(words); i++ {
+		keyword := strings.ToUpper(words[i].word)
+		keywords = append(keywords, keyword)
+		if keyword == "JOIN" {
+			break
+		}
+	}
+
+	var join updateJoin
+	switch kind := strings.Join(keywords, " "); kind {
+	case "JOIN", "INNER JOIN", "CROSS JOIN":
+	case "LEFT JOIN", "LEFT OUTER JOIN":
+		join.left = true
+	default:
+		return updateJoin{}, fmt.Errorf("%w: %s", ErrUnsupportedJoin, kind)
+	}
+
+	tableEnd := end
+	for _, w := range words[i+1:] {
+		word := strings.ToUpper(w.word)
+		if word == "USING" {
+			return updateJoin{}, fmt.Errorf("%w: USING is not supported, use ON instead", ErrUnsupportedJoin)
+		}
+
+		if word == "ON" {
+			tableEnd = w.start
+			join.on = strings.TrimSpace(sqlStr[w.end:end])
+			break
+		}
+	}
+
+	join.table = strings.TrimSpace(sqlStr[words[i].end:tableEnd])
+	if join.table == "" {
+		return updateJoin{}, fmt.Errorf("%w: missing table: %s", ErrUnsupportedJoin, sqlStr)
+	}
+
+	return join, nil
+}
+
+// takeArgs takes the arguments of the ? placeholders in the SQL fragment from the front of args
+func takeArgs(sqlStr string, args []any, syntax sqlSyntax) (taken, rest []any, ok bool) {
+	n := countPlaceholders(sqlStr, syntax)
+	if n > len(args) {
+		return nil, nil, false
+	}
+
+	return append(make([]any, 0, n), args[:n]...), args[n:], true
+}
+
+// topLevelWords returns the words of the SQL string that are outside any parentheses, quotes or comments. The quoted
+// parts of the string are read with the given syntax.
+func topLevelWords(sqlStr string, syntax sqlSyntax) []sqlWord {
+	words := make([]sqlWord, 0)
+	depth := 0
+	start := -1
+
+	l := newSQLLexer(sqlStr, syntax)
+	for l.pos < len(sqlStr) {
+		i := l.pos
+		r, size := utf8.DecodeRuneInString(sqlStr[i:])
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+		if start >= 0 && !isWord {
+			words = append(words, sqlWord{word: sqlStr[start:i], start: start, end: i})
+			start = -1
+		}
+
+		switch {
+		case isWord:
+			if depth == 0 && start < 0 {
+				start = i
+			}
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case l.skipQuotedPart():
+			continue
+		}
+		l.pos += size
+	}
+
+	if start >= 0 {
+		words = append(words, sqlWord{word: sqlStr[start:], start: start, end: len(sqlStr)})
+	}
+
+	return words
+}
@@ -0,0 +1,356 @@
+package patcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestParseJoins(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sql      string
+		args     []any
+		syntax   sqlSyntax
+		expected []updateJoin
+	}{
+		{
+			name: "Single join",
+			sql:  "JOIN teams t ON t.id = users.team_id AND t.name = ?",
+			args: []any{"team"},
+			expected: []updateJoin{
+				{table: "teams t", tableArgs: []any{}, on: "t.id = users.team_id AND t.name = ?", onArgs: []any{"team"}},
+			},
+		},
+		{
+			name: "Multiple joins",
+			sql:  "inner join teams AS t on t.id = users.team_id left outer join orgs o ON o.id = t.org_id AND o.active = ?",
+			args: []any{true},
+			expected: []updateJoin{
+				{table: "teams AS t", tableArgs: []any{}, on: "t.id = users.team_id", onArgs: []any{}},
+				{left: true, table: "orgs o", tableArgs: []any{}, on: "o.id = t.org_id AND o.active = ?", onArgs: []any{true}},
+			},
+		},
+		{
+			name: "Subquery",
+			sql:  "JOIN (SELECT id FROM teams WHERE name = ? AND 'on join' <> ?) t ON t.id = users.team_id AND t.id > ?",
+			args: []any{"team", "x", 1},
+			expected: []updateJoin{
+				{
+					table:     "(SELECT id FROM teams WHERE name = ? AND 'on join' <> ?) t",
+					tableArgs: []any{"team", "x"},
+					on:        "t.id = users.team_id AND t.id > ?",
+					onArgs:    []any{1},
+				},
+			},
+		},
+		{
+			name: "Comments",
+			sql:  "JOIN teams t /* left join */ ON t.id = users.team_id -- join orgs\nAND t.id > ?",
+			args: []any{1},
+			expected: []updateJoin{
+				{
+					table:     "teams t /* left join */",
+					tableArgs: []any{},
+					on:        "t.id = users.team_id -- join orgs\nAND t.id > ?",
+					onArgs:    []any{1},
+				},
+			},
+		},
+		{
+			name:   "Backslash escapes",
+			sql:    `JOIN teams t ON t.note <> 'it\'s a join' AND t.name <> "on \"join\"" AND t.id = ?`,
+			args:   []any{1},
+			syntax: sqlSyntax{backslashEscapes: true},
+			expected: []updateJoin{
+				{
+					table:     "teams t",
+					tableArgs: []any{},
+					on:        `t.note <> 'it\'s a join' AND t.name <> "on \"join\"" AND t.id = ?`,
+					onArgs:    []any{1},
+				},
+			},
+		},
+		{
+			name:   "Bracket identifiers",
+			sql:    "JOIN teams t ON t.[left join] = users.team_id AND t.id = ?",
+			args:   []any{1},
+			syntax: sqlSyntax{bracketIdentifiers: true},
+			expected: []updateJoin{
+				{table: "teams t", tableArgs: []any{}, on: "t.[left join] = users.team_id AND t.id = ?", onArgs: []any{1}},
+			},
+		},
+		{
+			name: "Cross join",
+			sql:  "CROSS JOIN settings",
+			expected: []updateJoin{
+				{table: "settings", tableArgs: []any{}, onArgs: []any{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			joins, err := parseJoins(tt.sql, tt.args, tt.syntax)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, joins)
+		})
+	}
+}
+
+func TestParseJoins_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		args []any
+		msg  string
+	}{
+		{
+			name: "Missing join keyword",
+			sql:  "teams t ON t.id = users.team_id",
+			msg:  "expected a JOIN keyword",
+		},
+		{
+			name: "Right join",
+			sql:  "RIGHT JOIN teams t ON t.id = users.team_id",
+			msg:  "RIGHT JOIN",
+		},
+		{
+			name: "Using",
+			sql:  "JOIN teams USING (team_id)",
+			msg:  "USING is not supported",
+		},
+		{
+			name: "Missing table",
+			sql:  "JOIN ON t.id = users.team_id",
+			msg:  "missing table",
+		},
+		{
+			name: "Too few arguments",
+			sql:  "JOIN teams t ON t.id = ?",
+			msg:  "placeholders do not match the arguments",
+		},
+		{
+			name: "Too many arguments",
+			sql:  "JOIN teams t ON t.id = users.team_id",
+			args: []any{1},
+			msg:  "placeholders do not match the arguments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			joins, err := parseJoins(tt.sql, tt.args, sqlSyntax{})
+			require.ErrorIs(t, err, ErrUnsupportedJoin)
+			require.ErrorContains(t, err, tt.msg)
+			require.Nil(t, joins)
+		})
+	}
+}
+
+type updateJoinSuite struct {
+	suite.Suite
+}
+
+func TestUpdateJoinSuite(t *testing.T) {
+	suite.Run(t, new(updateJoinSuite))
+}
+
+type updateJoinUser struct {
+	ID      int    `db:"id,pk"`
+	Name    string `db:"name"`
+	Version int    `db:"version" patcher:"version"`
+}
+
+func (s *updateJoinSuite) TestGenerateSQL() {
+	teams := InnerJoin("teams").As("t").On(
+		OnColumns("t.id", "users.team_id"),
+		&whereStringOption{where: "t.name = ?", args: []any{"team"}},
+	)
+	orgs := LeftJoin("orgs").As("o").On(
+		OnColumns("o.id", "t.org_id"),
+		&whereStringOption{where: "o.active = ?", args: []any{true}},
+	)
+	filter := &whereStringOption{where: "users.age > ?", args: []any{18}}
+
+	tests := []struct {
+		name     string
+		dialect  SQLDialect
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:    "MySQL",
+			dialect: DialectMySQL,
+			wantSQL: "UPDATE users\n" +
+				"INNER JOIN teams AS t ON t.id = users.team_id AND t.name = ?\n" +
+				"LEFT JOIN orgs AS o ON o.id = t.org_id AND o.active = ?\n" +
+				"SET name = ?, version = version + 1\n" +
+				"WHERE (1=1)\n" +
+				"AND (\n" +
+				"users.age > ?\n" +
+				")\n" +
+				"AND version = ?",
+			wantArgs: []any{"team", true, "John", 18, 3},
+		},
+		{
+			name:    "PostgreSQL",
+			dialect: DialectPostgreSQL,
+			wantSQL: "UPDATE users\n" +
+				"SET name = $1, version = version + 1\n" +
+				"FROM teams AS t LEFT JOIN orgs AS o ON o.id = t.org_id AND o.active = $2\n" +
+				"WHERE (1=1)\n" +
+				"AND (t.id = users.team_id AND t.name = $3)\n" +
+				"AND (\n" +
+				"users.age > $4\n" +
+				")\n" +
+				"AND version = $5",
+			wantArgs: []any{"John", true, "team", 18, 3},
+		},
+		{
+			name:    "SQLite",
+			dialect: DialectSQLite,
+			wantSQL: "UPDATE users\n" +
+				"SET name = ?, version = version + 1\n" +
+				"FROM teams AS t LEFT JOIN orgs AS o ON o.id = t.org_id AND o.active = ?\n" +
+				"WHERE (1=1)\n" +
+				"AND (t.id = users.team_id AND t.name = ?)\n" +
+				"AND (\n" +
+				"users.age > ?\n" +
+				")\n" +
+				"AND version = ?",
+			wantArgs: []any{"John", true, "team", 18, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			sqlStr, args, err := NewSQLPatch(&updateJoinUser{ID: 1, Name: "John", Version: 3},
+				WithTable("users"),
+				WithDialect(tt.dialect),
+				WithJoin(teams),
+				WithJoin(orgs),
+				WithWhere(filter),
+			).GenerateSQL()
+			s.Require().NoError(err)
+			s.Equal(tt.wantSQL, sqlStr)
+			s.Equal(tt.wantArgs, args)
+		})
+	}
+}
+
+func (s *updateJoinSuite) TestGenerateSQL_MultipleInnerJoins() {
+	type user struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	sqlStr, args, err := NewSQLPatch(&user{ID: 1, Name: "John"},
+		WithTable("users"),
+		WithDialect(DialectPostgreSQL),
+		WithJoinStr("JOIN teams t ON t.id = users.team_id AND t.name = ?", "team"),
+		WithJoin(InnerJoin("orgs", OnColumns("orgs.id", "t.org_id"))),
+		WithJoin(InnerJoin("settings")),
+		WithReturning("id"),
+	).GenerateSQL()
+	s.Require().NoError(err)
+
+	s.Equal("UPDATE users\n"+
+		"SET name = $1\n"+
+		"FROM teams t, orgs, settings\n"+
+		"WHERE (1=1)\n"+
+		"AND (t.id = users.team_id AND t.name = $2)\n"+
+		"AND (orgs.id = t.org_id)\n"+
+		"AND (\n"+
+		"id = $3\n"+
+		")\n"+
+		"RETURNING id", sqlStr)
+	s.Equal([]any{"John", "team", 1}, args)
+}
+
+func (s *updateJoinSuite) TestGenerateSQL_Errors() {
+	tests := []struct {
+		name string
+		join Joiner
+		msg  string
+	}{
+		{
+			name: "First join is a left join",
+			join: LeftJoin("teams", OnColumns("teams.id", "users.team_id")),
+			msg:  "the first join cannot be a LEFT JOIN: teams",
+		},
+		{
+			name: "Right join",
+			join: &joinStringOption{join: "RIGHT JOIN teams ON teams.id = users.team_id"},
+			msg:  "join is not supported by the SQL dialect: RIGHT JOIN",
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, _, err := NewSQLPatch(&updateJoinUser{ID: 1, Name: "John"},
+				WithTable("users"),
+				WithDialect(DialectSQLite),
+				WithJoin(tt.join),
+			).GenerateSQL()
+			s.Require().ErrorIs(err, ErrUnsupportedJoin)
+			s.Require().ErrorContains(err, tt.msg)
+
+			// MySQL supports any join in an UPDATE statement
+			_, _, err = NewSQLPatch(&updateJoinUser{ID: 1, Name: "John"},
+				WithTable("users"),
+				WithJoin(tt.join),
+			).GenerateSQL()
+			s.Require().NoError(err)
+		})
+	}
+}
+
+func (s *updateJoinSuite) TestGenerateSQL_LeftJoinReferencingTable() {
+	tests := []struct {
+		name string
+		join string
+	}{
+		{
+			name: "Unquoted table",
+			join: "JOIN teams t ON t.id = users.team_id LEFT JOIN orgs o ON o.id = users.org_id",
+		},
+		{
+			name: "Quoted table",
+			join: `JOIN teams t ON t.id = users.team_id LEFT JOIN orgs o ON o.id = "Users".org_id`,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			// The updated table cannot be referenced from within the FROM clause
+			sqlStr, args, err := NewSQLPatch(&updateJoinUser{ID: 1, Name: "John"},
+				WithTable("users"),
+				WithDialect(DialectPostgreSQL),
+				WithJoinStr(tt.join),
+			).GenerateSQL()
+			s.Require().ErrorIs(err, ErrUnsupportedJoin)
+			s.Require().ErrorContains(err, "the LEFT JOIN orgs o cannot reference the updated table users")
+			s.Empty(sqlStr)
+			s.Nil(args)
+		})
+	}
+
+	// A LEFT JOIN referencing only the joined tables is kept in the FROM clause
+	sqlStr, _, err := NewSQLPatch(&updateJoinUser{ID: 1, Name: "John"},
+		WithTable("users"),
+		WithDialect(DialectPostgreSQL),
+		WithJoinStr("JOIN teams t ON t.id = users.team_id LEFT JOIN orgs o ON o.id = t.org_id"),
+	).GenerateSQL()
+	s.Require().NoError(err)
+	s.Contains(sqlStr, "FROM teams t LEFT JOIN orgs o ON o.id = t.org_id\n")
+}
@@ -1,8 +1,11 @@
 package patcher
 
 import (
+	"database/sql/driver"
 	"reflect"
 	"strings"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
 )
 
 // ptr returns a pointer to the value passed in.
@@ -34,16 +37,89 @@ func ensureStruct(resource any) {
 }
 
 func getTag(fType *reflect.StructField, tagName string) string {
-	tag := fType.Tag.Get(tagName)
-	if tag == "" {
-		tag = strings.ToLower(fType.Name)
+	return fieldmeta.ColumnName(fType, tagName)
+}
+
+// isNilable checks if the value is of a kind that can be nil and is stored as a database field.
+func isNilable(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		return true
+	default:
+		return false
+	}
+}
+
+// asValuer returns the value as a driver.Valuer if its type, or a pointer to its type, implements the interface.
+func asValuer(val reflect.Value) (driver.Valuer, bool) {
+	if !val.IsValid() || (val.Kind() == reflect.Ptr && val.IsNil()) {
+		return nil, false
 	}
 
-	tags := strings.Split(tag, TagOptSeparator)
-	if len(tags) > 1 {
-		return tags[0]
+	if val.Type().Implements(fieldmeta.ValuerType) {
+		valuer, ok := val.Interface().(driver.Valuer)
+		return valuer, ok
+	}
+
+	if reflect.PointerTo(val.Type()).Implements(fieldmeta.ValuerType) {
+		ptrVal := reflect.New(val.Type())
+		ptrVal.Elem().Set(val)
+		valuer, ok := ptrVal.Interface().(driver.Valuer)
+		return valuer, ok
+	}
+
+	return nil, false
+}
+
+// isNullValuer checks if the value implements driver.Valuer and resolves to NULL, such as sql.NullString with
+// Valid set to false.
+func isNullValuer(val reflect.Value) bool {
+	valuer, ok := asValuer(val)
+	if !ok {
+		return false
+	}
+
+	value, err := valuer.Value()
+	return err == nil && value == nil
+}
+
+// cloneStruct returns a copy of the struct value where the pointers to flattened structs are copied as well, so that
+// changes made through the pointers of the original are not reflected in the copy.
+func cloneStruct(v reflect.Value) reflect.Value {
+	clone := reflect.New(v.Type()).Elem()
+	clone.Set(v)
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if _, ok := fieldmeta.FlattenPrefix(&field); !ok {
+			continue
+		}
+
+		fVal := v.Field(i)
+		switch {
+		case fVal.Kind() != reflect.Ptr:
+			clone.Field(i).Set(cloneStruct(fVal))
+		case !fVal.IsNil():
+			ptrClone := reflect.New(fVal.Type().Elem())
+			ptrClone.Elem().Set(cloneStruct(fVal.Elem()))
+			clone.Field(i).Set(ptrClone)
+		}
+	}
+
+	return clone
+}
+
+// valuesEqual checks if the values are deeply equal. Values of basic kinds are compared directly, avoiding the
+// allocations of reflect.DeepEqual.
+func valuesEqual(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return a.Equal(b)
+	default:
+		return reflect.DeepEqual(a.Interface(), b.Interface())
 	}
-	return tag
 }
 
 func getValue(fVal reflect.Value) any {
@@ -57,14 +133,7 @@ func getValue(fVal reflect.Value) any {
 
 // IsValidType checks if the given value is of a type that can be stored as a database field.
 func IsValidType(val reflect.Value) bool {
-	switch val.Kind() {
-	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
-		reflect.Float32, reflect.Float64, reflect.String, reflect.Struct, reflect.Ptr:
-		return true
-	default:
-		return false
-	}
+	return fieldmeta.ValidKind(val.Kind())
 }
 
 func getTableName(resource any) string {
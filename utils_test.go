@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/patcher/internal/fieldmeta"
 )
 
 func TestIsPointerToStruct(t *testing.T) {
@@ -230,3 +232,21 @@ func TestToSnakeCase(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldMeta_PatcherTypes(t *testing.T) {
+	t.Parallel()
+
+	type testObj struct {
+		Count Optional[int]
+		Score Expression
+	}
+
+	fields := fieldmeta.TypeFields(reflect.TypeFor[testObj](), DefaultDbTagName)
+	require.Len(t, fields, 2)
+
+	require.True(t, fields[0].Optional)
+	require.True(t, fields[0].Valuer)
+	require.False(t, fields[0].Nested)
+	require.True(t, fields[1].Expression)
+	require.False(t, fields[1].Nested)
+}
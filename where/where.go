@@ -0,0 +1,200 @@
+// Package where provides composable predicates for the WHERE clause of a patch. Every predicate implements
+// patcher.Wherer and patcher.WhereTyper, so it can be passed straight to patcher.WithWhere or MultiFilter.Add:
+//
+//	patcher.NewSQLPatch(user,
+//		patcher.WithWhere(where.Eq("id", 1)),
+//		patcher.WithWhere(where.In("status", []string{"active", "pending"})),
+//	)
+//
+// Values are always bound as arguments. The placeholders are written as "?" and converted to the dialect of the patch
+// when the SQL is generated.
+package where
+
+import (
+	"strings"
+
+	"github.com/jacobbrewer1/patcher"
+)
+
+// LikeEscape is the escape character used by Contains, StartsWith and EndsWith. It is set explicitly with the ESCAPE
+// clause, as the default escape character differs between databases.
+const LikeEscape = '!'
+
+var _ patcher.WhereTyper = (*Predicate)(nil)
+
+// Predicate is a condition of the WHERE clause
+type Predicate struct {
+	// sql is the condition with "?" placeholders
+	sql string
+
+	// args are the arguments of the placeholders
+	args []any
+
+	// whereType is the type used to join the condition to the previous conditions
+	whereType patcher.WhereType
+}
+
+// newPredicate creates a predicate joined to the previous conditions with AND
+func newPredicate(sql string, args ...any) *Predicate {
+	return &Predicate{
+		sql:       sql,
+		args:      args,
+		whereType: patcher.WhereTypeAnd,
+	}
+}
+
+// Where returns the condition and its arguments
+func (p *Predicate) Where() (sqlStr string, args []any) {
+	return p.sql, p.args
+}
+
+// WhereType returns the type used to join the condition to the previous conditions
+func (p *Predicate) WhereType() patcher.WhereType {
+	return p.whereType
+}
+
+// Or returns a copy of the predicate that is joined to the previous conditions with OR
+func (p *Predicate) Or() *Predicate {
+	or := *p
+	or.whereType = patcher.WhereTypeOr
+	return &or
+}
+
+// Eq creates a predicate checking that the column equals the value. A nil value checks that the column is NULL.
+func Eq(column string, value any) *Predicate {
+	if value == nil {
+		return IsNull(column)
+	}
+
+	return compare(column, "=", value)
+}
+
+// Neq creates a predicate checking that the column does not equal the value. A nil value checks that the column is
+// not NULL.
+func Neq(column string, value any) *Predicate {
+	if value == nil {
+		return IsNotNull(column)
+	}
+
+	return compare(column, "<>", value)
+}
+
+// Gt creates a predicate checking that the column is greater than the value
+func Gt(column string, value any) *Predicate {
+	return compare(column, ">", value)
+}
+
+// Gte creates a predicate checking that the column is greater than or equal to the value
+func Gte(column string, value any) *Predicate {
+	return compare(column, ">=", value)
+}
+
+// Lt creates a predicate checking that the column is less than the value
+func Lt(column string, value any) *Predicate {
+	return compare(column, "<", value)
+}
+
+// Lte creates a predicate checking that the column is less than or equal to the value
+func Lte(column string, value any) *Predicate {
+	return compare(column, "<=", value)
+}
+
+// compare creates a predicate comparing the column to the value with the operator
+func compare(column, operator string, value any) *Predicate {
+	return newPredicate(column+" "+operator+" ?", value)
+}
+
+// In creates a predicate checking that the column equals one of the values. The slice is expanded so that each value is
+// bound to its own placeholder. With no values, the predicate never matches.
+func In[T any](column string, values []T) *Predicate {
+	if len(values) == 0 {
+		return newPredicate("1=0")
+	}
+
+	return newPredicate(column+" IN ("+placeholders(len(values))+")", toArgs(values)...)
+}
+
+// NotIn creates a predicate checking that the column equals none of the values. The slice is expanded so that each
+// value is bound to its own placeholder. With no values, the predicate always matches.
+func NotIn[T any](column string, values []T) *Predicate {
+	if len(values) == 0 {
+		return newPredicate("1=1")
+	}
+
+	return newPredicate(column+" NOT IN ("+placeholders(len(values))+")", toArgs(values)...)
+}
+
+// Between creates a predicate checking that the column is between the values, inclusive
+func Between(column string, from, to any) *Predicate {
+	return newPredicate(column+" BETWEEN ? AND ?", from, to)
+}
+
+// IsNull creates a predicate checking that the column is NULL
+func IsNull(column string) *Predicate {
+	return newPredicate(column + " IS NULL")
+}
+
+// IsNotNull creates a predicate checking that the column is not NULL
+func IsNotNull(column string) *Predicate {
+	return newPredicate(column + " IS NOT NULL")
+}
+
+// Like creates a predicate matching the column against the LIKE pattern. The pattern is used as is, so the % and _
+// wildcards in it are applied. Use Contains, StartsWith or EndsWith to match user input literally.
+func Like(column, pattern string) *Predicate {
+	return newPredicate(column+" LIKE ?", pattern)
+}
+
+// Contains creates a predicate checking that the column contains the value. Wildcards in the value are escaped.
+func Contains(column, value string) *Predicate {
+	return likeEscaped(column, "%"+EscapeLike(value)+"%")
+}
+
+// StartsWith creates a predicate checking that the column starts with the value. Wildcards in the value are escaped.
+func StartsWith(column, value string) *Predicate {
+	return likeEscaped(column, EscapeLike(value)+"%")
+}
+
+// EndsWith creates a predicate checking that the column ends with the value. Wildcards in the value are escaped.
+func EndsWith(column, value string) *Predicate {
+	return likeEscaped(column, "%"+EscapeLike(value))
+}
+
+// likeEscaped creates a LIKE predicate for a pattern escaped with LikeEscape
+func likeEscaped(column, pattern string) *Predicate {
+	return newPredicate(column+" LIKE ? ESCAPE '"+string(LikeEscape)+"'", pattern)
+}
+
+// EscapeLike escapes the % and _ wildcards and the escape character in the value with LikeEscape, so that the value is
+// matched literally in a LIKE pattern using ESCAPE '!'.
+func EscapeLike(value string) string {
+	if !strings.ContainsAny(value, "%_"+string(LikeEscape)) {
+		return value
+	}
+
+	escaped := new(strings.Builder)
+	escaped.Grow(len(value) + 4)
+	for _, r := range value {
+		if r == '%' || r == '_' || r == LikeEscape {
+			escaped.WriteRune(LikeEscape)
+		}
+		escaped.WriteRune(r)
+	}
+
+	return escaped.String()
+}
+
+// placeholders returns n comma separated placeholders
+func placeholders(n int) string {
+	return strings.Repeat("?, ", n-1) + "?"
+}
+
+// toArgs converts the values to arguments
+func toArgs[T any](values []T) []any {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return args
+}
@@ -0,0 +1,121 @@
+package where
+
+import (
+	"testing"
+
+	"github.com/jacobbrewer1/patcher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPredicates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		predicate    *Predicate
+		expectedSQL  string
+		expectedArgs []any
+	}{
+		{"Eq", Eq("id", 1), "id = ?", []any{1}},
+		{"Eq nil", Eq("deleted_at", nil), "deleted_at IS NULL", nil},
+		{"Neq", Neq("status", "deleted"), "status <> ?", []any{"deleted"}},
+		{"Neq nil", Neq("deleted_at", nil), "deleted_at IS NOT NULL", nil},
+		{"Gt", Gt("age", 18), "age > ?", []any{18}},
+		{"Gte", Gte("age", 18), "age >= ?", []any{18}},
+		{"Lt", Lt("age", 65), "age < ?", []any{65}},
+		{"Lte", Lte("age", 65), "age <= ?", []any{65}},
+		{"In", In("id", []int{1, 2, 3}), "id IN (?, ?, ?)", []any{1, 2, 3}},
+		{"In single value", In("status", []string{"active"}), "status IN (?)", []any{"active"}},
+		{"In empty", In("id", []int{}), "1=0", nil},
+		{"NotIn", NotIn("id", []int{1, 2}), "id NOT IN (?, ?)", []any{1, 2}},
+		{"NotIn empty", NotIn("id", []int(nil)), "1=1", nil},
+		{"Between", Between("age", 18, 65), "age BETWEEN ? AND ?", []any{18, 65}},
+		{"IsNull", IsNull("deleted_at"), "deleted_at IS NULL", nil},
+		{"IsNotNull", IsNotNull("deleted_at"), "deleted_at IS NOT NULL", nil},
+		{"Like", Like("name", "jo%n_"), "name LIKE ?", []any{"jo%n_"}},
+		{"Contains", Contains("name", "50%_off!"), "name LIKE ? ESCAPE '!'", []any{"%50!%!_off!!%"}},
+		{"StartsWith", StartsWith("name", "jo_"), "name LIKE ? ESCAPE '!'", []any{"jo!_%"}},
+		{"EndsWith", EndsWith("name", "son"), "name LIKE ? ESCAPE '!'", []any{"%son"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlStr, args := tt.predicate.Where()
+			require.Equal(t, tt.expectedSQL, sqlStr)
+			require.Equal(t, tt.expectedArgs, args)
+			require.Equal(t, patcher.WhereTypeAnd, tt.predicate.WhereType())
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"No wildcards", "john", "john"},
+		{"Percent", "100%", "100!%"},
+		{"Underscore", "first_name", "first!_name"},
+		{"Escape character", "hi!", "hi!!"},
+		{"Unicode", "ü_ß%", "ü!_ß!%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, EscapeLike(tt.value))
+		})
+	}
+}
+
+func TestPredicate_Or(t *testing.T) {
+	t.Parallel()
+
+	and := Eq("id", 1)
+	or := and.Or()
+
+	require.Equal(t, patcher.WhereTypeOr, or.WhereType())
+	require.Equal(t, patcher.WhereTypeAnd, and.WhereType())
+
+	sqlStr, args := or.Where()
+	require.Equal(t, "id = ?", sqlStr)
+	require.Equal(t, []any{1}, args)
+}
+
+func TestPredicates_GenerateSQL(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	sqlStr, args, err := patcher.GenerateSQL(
+		&user{Name: "john"},
+		patcher.WithTable("users"),
+		patcher.WithDialect(patcher.DialectPostgreSQL),
+		patcher.WithWhere(In("id", []int{1, 2, 3})),
+		patcher.WithWhere(IsNull("deleted_at").Or()),
+		patcher.WithWhere(Contains("email", "_admin")),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "UPDATE users\nSET name = $1\nWHERE (1=1)\nAND (\nid IN ($2, $3, $4)\nOR deleted_at IS NULL\nAND email LIKE $5 ESCAPE '!'\n)", sqlStr)
+	require.Equal(t, []any{"john", 1, 2, 3, "%!_admin%"}, args)
+}
+
+func TestPredicates_MultiFilter(t *testing.T) {
+	t.Parallel()
+
+	filter := patcher.NewMultiFilter()
+	filter.Add(Eq("org_id", 7))
+	filter.Add(Between("age", 18, 65).Or())
+
+	sqlStr, args := filter.Where()
+	require.Equal(t, "AND org_id = ?\nOR age BETWEEN ? AND ?\n", sqlStr)
+	require.Equal(t, []any{7, 18, 65}, args)
+}
@@ -4,7 +4,8 @@ import "strings"
 
 // Wherer is an interface that can be used to specify the WHERE clause to use. By using this interface,
 // the package will default to using an "AND" WHERE clause. If you want to use an "OR" WHERE clause, you can
-// use the WhereTyper interface instead.
+// use the WhereTyper interface instead. The condition may use ? or $1, $2, $3 placeholders, which are numbered again
+// when the SQL is generated.
 type Wherer interface {
 	Where() (string, []any)
 }
@@ -31,11 +32,30 @@ func (w WhereType) IsValid() bool {
 	return false
 }
 
-func appendWhere(where Wherer, builder *strings.Builder, args *[]any) {
+// appendWhere adds the condition of the filter to the WHERE clause. The $1, $2, $3 placeholders of the condition are
+// converted to ? placeholders, and an error is returned if the placeholders do not match the arguments. The quoted
+// parts of the condition are read with the given syntax.
+func appendWhere(where Wherer, builder *strings.Builder, args *[]any, syntax sqlSyntax) error {
 	if where == nil {
-		return
+		return nil
+	}
+	var (
+		wSQL   string
+		fwArgs []any
+		err    error
+	)
+	if grouper, ok := where.(whereGrouper); ok {
+		wSQL, fwArgs, err = grouper.groupWhere(syntax)
+		if err == nil && wSQL == "" {
+			return nil
+		}
+	} else {
+		wSQL, fwArgs = where.Where()
+		wSQL, fwArgs, err = normalizePlaceholders(strings.TrimSpace(wSQL), fwArgs, syntax)
+	}
+	if err != nil {
+		return err
 	}
-	wSQL, fwArgs := where.Where()
 	if fwArgs == nil {
 		fwArgs = make([]any, 0)
 	}
@@ -48,6 +68,14 @@ func appendWhere(where Wherer, builder *strings.Builder, args *[]any) {
 	builder.WriteString(strings.TrimSpace(wSQL))
 	builder.WriteString("\n")
 	*args = append(*args, fwArgs...)
+	return nil
+}
+
+// appendWhere adds the filter to the where clause of the patch, recording the first error encountered
+func (s *SQLPatch) appendWhere(where Wherer) {
+	if err := appendWhere(where, s.whereSql, &s.whereArgs, dialectSyntax(s.dialect)); err != nil && s.genErr == nil {
+		s.genErr = err
+	}
 }
 
 type whereStringOption struct {